@@ -9,17 +9,17 @@ import (
 
 // ChatBatchProcessor runs multiple chat requests concurrently.
 type ChatBatchProcessor struct {
-	client      *chat.Service
+	client      chat.Client
 	concurrency int
 }
 
 // NewChatBatchProcessor creates a batch processor for chat requests.
-func NewChatBatchProcessor(chatService *chat.Service, concurrency int) *ChatBatchProcessor {
+func NewChatBatchProcessor(client chat.Client, concurrency int) *ChatBatchProcessor {
 	if concurrency <= 0 {
 		concurrency = 5
 	}
 	return &ChatBatchProcessor{
-		client:      chatService,
+		client:      client,
 		concurrency: concurrency,
 	}
 }
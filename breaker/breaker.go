@@ -0,0 +1,357 @@
+// Package breaker provides circuit breakers keyed by model and upstream provider.
+//
+// A circuit opens when the failure ratio within a sliding window exceeds the
+// configured threshold. While open, requests for that model fail fast with
+// *errors.CircuitOpenError. After OpenTimeout the circuit half-opens and lets
+// a limited number of probe requests through; it closes again once they succeed.
+//
+// Provider circuits are fed from the provider reported on responses and error
+// metadata. They never block requests (the provider is only known afterwards)
+// but can be used to steer routing, e.g. via ProviderPreferences.Ignore.
+package breaker
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// Default configuration values.
+const (
+	DefaultWindow       = time.Minute
+	DefaultMinRequests  = 10
+	DefaultFailureRatio = 0.5
+	DefaultOpenTimeout  = 30 * time.Second
+	DefaultProbes       = 1
+)
+
+const (
+	modelPrefix    = "model:"
+	providerPrefix = "provider:"
+)
+
+// ModelKey returns the circuit key for a model ID.
+func ModelKey(model string) string { return modelPrefix + model }
+
+// ProviderKey returns the circuit key for an upstream provider name.
+func ProviderKey(provider string) string { return providerPrefix + provider }
+
+// State is the state of a circuit.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+// String returns the state name.
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Config configures a Breaker. Zero values use the defaults above.
+type Config struct {
+	// Window is the period over which failures are counted. It slides in
+	// steps of a tenth of its length.
+	Window time.Duration
+	// MinRequests is the number of requests in a window before the circuit may open.
+	MinRequests int
+	// FailureRatio opens the circuit when failures/requests reaches it.
+	FailureRatio float64
+	// OpenTimeout is how long a circuit stays open before probing.
+	OpenTimeout time.Duration
+	// Probes is the number of successful probe requests needed to close a
+	// half-open circuit; it is also the number of probes allowed in flight.
+	Probes int
+	// IsFailure decides which errors count against a circuit. Defaults to
+	// DefaultIsFailure.
+	IsFailure func(err error) bool
+	// OnStateChange, if set, is called (without locks held) on every transition.
+	OnStateChange func(key string, from, to State)
+}
+
+// DefaultIsFailure counts server-side failures: timeouts, 5xx responses and
+// network errors. Client errors (4xx other than 408) and the caller's context
+// being canceled or reaching its deadline do not indicate a degraded model.
+func DefaultIsFailure(err error) bool {
+	if err == nil || callerDone(err) {
+		return false
+	}
+	var oerr *errors.OpenRouterError
+	if stderrors.As(err, &oerr) {
+		return oerr.Code == 408 || oerr.Code >= 500
+	}
+	var cerr *errors.CircuitOpenError
+	return !stderrors.As(err, &cerr)
+}
+
+// callerDone reports whether err is the caller's context ending. Client
+// timeouts also match context.DeadlineExceeded under errors.Is, so the
+// deadline is matched by identity instead.
+func callerDone(err error) bool {
+	if stderrors.Is(err, context.Canceled) {
+		return true
+	}
+	for e := err; e != nil; e = stderrors.Unwrap(e) {
+		if e == context.DeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats is a snapshot of one circuit, for dashboards.
+type Stats struct {
+	Key      string
+	State    State
+	Requests int // requests in the current window
+	Failures int // failures in the current window
+	OpenedAt time.Time
+}
+
+// Breaker tracks a set of circuits. It is safe for concurrent use.
+type Breaker struct {
+	cfg      Config
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// New creates a Breaker from cfg.
+func New(cfg Config) *Breaker {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultMinRequests
+	}
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = DefaultFailureRatio
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultOpenTimeout
+	}
+	if cfg.Probes <= 0 {
+		cfg.Probes = DefaultProbes
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = DefaultIsFailure
+	}
+	return &Breaker{cfg: cfg, circuits: make(map[string]*circuit)}
+}
+
+// Allow asks to send a request on the circuit for key. If the circuit is open
+// it returns *errors.CircuitOpenError. Otherwise the caller must report the
+// outcome by calling done exactly once.
+func (b *Breaker) Allow(key string) (done func(err error), err error) {
+	b.mu.Lock()
+	now := time.Now()
+	c := b.circuit(key)
+	from := c.state
+	if c.state == Open && now.Sub(c.openedAt) >= b.cfg.OpenTimeout {
+		c.toHalfOpen()
+	}
+	switch {
+	case c.state == Open:
+		until := c.openedAt.Add(b.cfg.OpenTimeout)
+		b.mu.Unlock()
+		return nil, &errors.CircuitOpenError{Key: key, Until: until}
+	case c.state == HalfOpen && c.probes >= b.cfg.Probes:
+		b.mu.Unlock()
+		return nil, &errors.CircuitOpenError{Key: key, Until: now}
+	case c.state == HalfOpen:
+		c.probes++
+	}
+	to := c.state
+	b.mu.Unlock()
+	b.notify(key, from, to)
+
+	var once sync.Once
+	probe := to == HalfOpen
+	return func(err error) {
+		once.Do(func() { b.record(key, err, probe) })
+	}, nil
+}
+
+// Record reports the outcome of a request that did not go through Allow, such
+// as the provider that served it.
+func (b *Breaker) Record(key string, err error) {
+	b.record(key, err, false)
+}
+
+// State returns the current state of the circuit for key.
+func (b *Breaker) State(key string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		return Closed
+	}
+	if c.state == Open && time.Since(c.openedAt) >= b.cfg.OpenTimeout {
+		return HalfOpen
+	}
+	return c.state
+}
+
+// Snapshot returns the stats of every known circuit, sorted by key.
+func (b *Breaker) Snapshot() []Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	out := make([]Stats, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		requests, failures := c.counts(now, b.cfg.Window)
+		state := c.state
+		if state == Open && now.Sub(c.openedAt) >= b.cfg.OpenTimeout {
+			state = HalfOpen
+		}
+		out = append(out, Stats{
+			Key:      key,
+			State:    state,
+			Requests: requests,
+			Failures: failures,
+			OpenedAt: c.openedAt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// OpenProviders returns the providers whose circuits are currently open.
+func (b *Breaker) OpenProviders() []string {
+	var out []string
+	for _, s := range b.Snapshot() {
+		if s.State == Open && strings.HasPrefix(s.Key, providerPrefix) {
+			out = append(out, strings.TrimPrefix(s.Key, providerPrefix))
+		}
+	}
+	return out
+}
+
+func (b *Breaker) record(key string, err error, probe bool) {
+	failed := b.cfg.IsFailure(err)
+	neutral := err != nil && !failed
+
+	b.mu.Lock()
+	now := time.Now()
+	c := b.circuit(key)
+	from := c.state
+	if probe && c.probes > 0 {
+		c.probes--
+	}
+	switch {
+	case neutral:
+	case c.state == HalfOpen && failed:
+		c.toOpen(now)
+	case c.state == HalfOpen:
+		c.successes++
+		if c.successes >= b.cfg.Probes {
+			c.toClosed()
+		}
+	case c.state == Closed:
+		c.add(now, b.cfg.Window, failed)
+		requests, failures := c.counts(now, b.cfg.Window)
+		if requests >= b.cfg.MinRequests && float64(failures)/float64(requests) >= b.cfg.FailureRatio {
+			c.toOpen(now)
+		}
+	}
+	to := c.state
+	b.mu.Unlock()
+	b.notify(key, from, to)
+}
+
+func (b *Breaker) notify(key string, from, to State) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(key, from, to)
+	}
+}
+
+// circuit returns the circuit for key, creating it as needed.
+// Callers must hold b.mu.
+func (b *Breaker) circuit(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// windowBuckets is the number of buckets a window is counted in.
+const windowBuckets = 10
+
+type circuit struct {
+	state     State
+	buckets   [windowBuckets]bucket
+	openedAt  time.Time
+	probes    int // probes in flight while half-open
+	successes int // successful probes while half-open
+}
+
+// bucket counts the outcomes in one slice of the window.
+type bucket struct {
+	slot     int64 // time slice, in units of window/windowBuckets
+	requests int
+	failures int
+}
+
+func slot(now time.Time, window time.Duration) int64 {
+	return now.UnixNano() / max(int64(window/windowBuckets), 1)
+}
+
+// add counts an outcome in the current bucket.
+func (c *circuit) add(now time.Time, window time.Duration, failed bool) {
+	s := slot(now, window)
+	b := &c.buckets[s%windowBuckets]
+	if b.slot != s {
+		*b = bucket{slot: s}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+// counts sums the buckets within the window ending at now.
+func (c *circuit) counts(now time.Time, window time.Duration) (requests, failures int) {
+	s := slot(now, window)
+	for _, b := range c.buckets {
+		if s-b.slot < windowBuckets {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests, failures
+}
+
+func (c *circuit) toOpen(now time.Time) {
+	c.state = Open
+	c.openedAt = now
+	c.probes = 0
+	c.successes = 0
+}
+
+func (c *circuit) toHalfOpen() {
+	c.state = HalfOpen
+	c.probes = 0
+	c.successes = 0
+}
+
+func (c *circuit) toClosed() {
+	c.state = Closed
+	c.buckets = [windowBuckets]bucket{}
+	c.probes = 0
+	c.successes = 0
+}
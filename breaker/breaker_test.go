@@ -0,0 +1,143 @@
+package breaker_test
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter"
+	"github.com/MetaDiv-AI/openrouter/breaker"
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/errors"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+)
+
+func TestDefaultIsFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"bad request", &errors.OpenRouterError{Code: 400}, false},
+		{"rate limited", &errors.OpenRouterError{Code: 429}, false},
+		{"request timeout", &errors.OpenRouterError{Code: 408}, true},
+		{"server error", &errors.OpenRouterError{Code: 502}, true},
+		{"network", &net.OpError{Op: "read", Err: stderrors.New("connection reset")}, true},
+		{"stream timeout", &errors.StreamTimeoutError{Kind: errors.IdleTimeout, Timeout: time.Second}, true},
+		{"caller canceled", fmt.Errorf("do request: %w", context.Canceled), false},
+		{"caller deadline", fmt.Errorf("do request: %w", context.DeadlineExceeded), false},
+		{"circuit open", &errors.CircuitOpenError{Key: breaker.ModelKey("m")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := breaker.DefaultIsFailure(tt.err); got != tt.want {
+				t.Errorf("DefaultIsFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	var transitions []string
+	b := breaker.New(breaker.Config{
+		MinRequests:  4,
+		FailureRatio: 0.5,
+		OpenTimeout:  20 * time.Millisecond,
+		OnStateChange: func(key string, from, to breaker.State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	key := breaker.ModelKey("m")
+	fail := &errors.OpenRouterError{Code: 500}
+
+	// Below MinRequests the circuit stays closed however many fail.
+	for range 3 {
+		b.Record(key, fail)
+	}
+	if got := b.State(key); got != breaker.Closed {
+		t.Fatalf("state after 3 failures = %v, want closed", got)
+	}
+	b.Record(key, fail)
+	if got := b.State(key); got != breaker.Open {
+		t.Fatalf("state after 4 failures = %v, want open", got)
+	}
+	_, err := b.Allow(key)
+	var cerr *errors.CircuitOpenError
+	if !stderrors.As(err, &cerr) {
+		t.Fatalf("Allow on open circuit = %v, want CircuitOpenError", err)
+	}
+
+	// After OpenTimeout one probe is let through; its failure reopens.
+	time.Sleep(30 * time.Millisecond)
+	done, err := b.Allow(key)
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err := b.Allow(key); err == nil {
+		t.Fatal("second probe allowed while the first is in flight")
+	}
+	done(fail)
+	if got := b.State(key); got != breaker.Open {
+		t.Fatalf("state after failed probe = %v, want open", got)
+	}
+
+	// A successful probe closes the circuit.
+	time.Sleep(30 * time.Millisecond)
+	done, err = b.Allow(key)
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	done(nil)
+	if got := b.State(key); got != breaker.Closed {
+		t.Fatalf("state after successful probe = %v, want closed", got)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestBreakerStreamTimeouts(t *testing.T) {
+	srv := openroutertest.NewServer(t)
+	b := breaker.New(breaker.Config{MinRequests: 100})
+	client := srv.Client(openrouter.WithCircuitBreaker(b))
+	req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+	key := breaker.ModelKey("m")
+
+	failures := func() int {
+		for _, s := range b.Snapshot() {
+			if s.Key == key {
+				return s.Failures
+			}
+		}
+		return 0
+	}
+
+	// A model that stalls before its first token counts against it.
+	srv.Chat(openroutertest.Text("slow reply").WithChunkDelay(time.Second))
+	err := client.Chat.CreateStreamFunc(context.Background(), req, func(*chat.StreamChunk) error { return nil },
+		chat.WithFirstTokenTimeout(20*time.Millisecond))
+	if !stderrors.Is(err, errors.ErrFirstTokenTimeout) {
+		t.Fatalf("err = %v, want first-token timeout", err)
+	}
+	if got := failures(); got != 1 {
+		t.Fatalf("failures after stream timeout = %d, want 1", got)
+	}
+
+	// The caller giving up does not.
+	srv.Chat(openroutertest.Text("slow reply").WithChunkDelay(time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.Chat.CreateStreamFunc(ctx, req, func(*chat.StreamChunk) error { return nil })
+	if err == nil {
+		t.Fatal("want an error from the caller's deadline")
+	}
+	if got := failures(); got != 1 {
+		t.Fatalf("failures after caller deadline = %d, want 1", got)
+	}
+}
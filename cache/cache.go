@@ -0,0 +1,363 @@
+// Package cache serves repeated chat and embeddings requests from a local
+// cache instead of the API.
+//
+// Chat and Embeddings wrap the client services. Requests are keyed by a hash
+// of their canonical JSON encoding, so identical requests (eval reruns,
+// temperature-0 prompts, repeated embeddings) hit the cache whichever process
+// or goroutine made them:
+//
+//	cached := cache.NewChat(client.Chat, cache.Config{
+//	    Store:     cache.NewLRU(10000),
+//	    TTL:       24 * time.Hour,
+//	    Cacheable: cache.Deterministic,
+//	})
+//
+// Cached chat responses are served to CreateStream as a synthetic stream, and
+// completed streams are cached like unary responses. Only successful
+// responses are stored. Store errors are reported to Config.OnError and
+// otherwise treated as misses, so a failing cache never fails a request.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/embeddings"
+	"github.com/MetaDiv-AI/openrouter/metrics"
+)
+
+// Config configures a cache layer.
+type Config struct {
+	// Store holds the entries. Nil uses an LRU of DefaultMaxEntries.
+	Store Store
+	// TTL is how long entries are kept. Zero keeps them until evicted.
+	TTL time.Duration
+	// Cacheable, if set, selects the chat requests to cache. Others go
+	// straight to the API.
+	Cacheable func(req *chat.ChatRequest) bool
+	// OnError, if set, is called with store and encoding errors.
+	OnError func(err error)
+	// Metrics, if set, records lookups as LookupsMetric.
+	Metrics metrics.Provider
+}
+
+// Control adjusts caching for one call. Attach it with WithControl.
+type Control struct {
+	// NoCache skips the lookup and calls the API; the response is still
+	// stored, refreshing the entry.
+	NoCache bool
+	// NoStore leaves the response out of the cache.
+	NoStore bool
+	// TTL, if positive, overrides Config.TTL for the stored response.
+	TTL time.Duration
+}
+
+type controlKey struct{}
+
+// WithControl returns a context applying c to cache layers called with it.
+func WithControl(ctx context.Context, c Control) context.Context {
+	return context.WithValue(ctx, controlKey{}, c)
+}
+
+func controlFrom(ctx context.Context) Control {
+	c, _ := ctx.Value(controlKey{}).(Control)
+	return c
+}
+
+// Deterministic reports whether req asks for reproducible output: a
+// temperature of 0 or a fixed seed. Use it as Config.Cacheable.
+func Deterministic(req *chat.ChatRequest) bool {
+	return (req.Temperature != nil && *req.Temperature == 0) || req.Seed != nil
+}
+
+// LookupsMetric counts cache lookups by cache ("exact" or "semantic"), model
+// and result ("hit", "miss", or "error" when the store failed).
+const LookupsMetric = "openrouter_cache_lookups_total"
+
+// Stats counts cache lookups.
+type Stats struct {
+	Hits   int64
+	Misses int64
+	// Errors are lookups the store failed, counted apart from misses.
+	Errors int64
+}
+
+// Lookup results, as counted in LookupsMetric.
+const (
+	resultHit   = "hit"
+	resultMiss  = "miss"
+	resultError = "error"
+)
+
+// keyVersion changes when the key derivation or the stored encoding does,
+// invalidating older entries.
+const keyVersion = "v1"
+
+// ChatKey returns the cache key of req. The Stream flag is ignored, so
+// streamed and unary calls share entries.
+func ChatKey(req *chat.ChatRequest) (string, error) {
+	r := *req
+	r.Stream = false
+	return key("chat", &r)
+}
+
+// EmbeddingsKey returns the cache key of req.
+func EmbeddingsKey(req *embeddings.CreateRequest) (string, error) {
+	return key("embeddings", req)
+}
+
+// key hashes the JSON encoding of v, which is canonical: struct fields are
+// encoded in declaration order and map keys sorted.
+func key(kind string, v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(keyVersion + "\x00" + kind + "\x00"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// layer holds what the cache clients share.
+type layer struct {
+	cfg     Config
+	kind    string
+	hits    atomic.Int64
+	misses  atomic.Int64
+	errors  atomic.Int64
+	lookups metrics.Counter
+}
+
+func (l *layer) init(kind string, cfg Config) {
+	l.cfg, l.kind = cfg, kind
+	if cfg.Metrics != nil {
+		l.lookups = cfg.Metrics.Counter(LookupsMetric, "Cache lookups by result (hit, miss or error).", "cache", "model", "result")
+	}
+}
+
+// Stats returns the number of lookups so far by result.
+func (l *layer) Stats() Stats {
+	return Stats{Hits: l.hits.Load(), Misses: l.misses.Load(), Errors: l.errors.Load()}
+}
+
+func (l *layer) fail(err error) {
+	if l.cfg.OnError != nil {
+		l.cfg.OnError(err)
+	}
+}
+
+// count records a lookup for model with one of the result constants.
+func (l *layer) count(model, result string) {
+	switch result {
+	case resultHit:
+		l.hits.Add(1)
+	case resultMiss:
+		l.misses.Add(1)
+	case resultError:
+		l.errors.Add(1)
+	}
+	if l.lookups != nil {
+		l.lookups.Add(1, l.kind, model, result)
+	}
+}
+
+// lookup decodes the entry under key into v, counting the result.
+func (l *layer) lookup(ctx context.Context, model, key string, v any) bool {
+	data, ok, err := l.cfg.Store.Get(ctx, key)
+	if err != nil {
+		l.fail(err)
+		l.count(model, resultError)
+		return false
+	}
+	if ok {
+		if err = json.Unmarshal(data, v); err != nil {
+			l.fail(err)
+			ok = false
+		}
+	}
+	l.count(model, hitOrMiss(ok))
+	return ok
+}
+
+func hitOrMiss(hit bool) string {
+	if hit {
+		return resultHit
+	}
+	return resultMiss
+}
+
+// store encodes v under key unless ctl says otherwise. It runs even if ctx
+// has been canceled since the response arrived.
+func (l *layer) store(ctx context.Context, ctl Control, key string, v any) {
+	if ctl.NoStore {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		l.fail(err)
+		return
+	}
+	ttl := l.cfg.TTL
+	if ctl.TTL > 0 {
+		ttl = ctl.TTL
+	}
+	if err := l.cfg.Store.Set(context.WithoutCancel(ctx), key, data, ttl); err != nil {
+		l.fail(err)
+	}
+}
+
+// Chat is a chat.Client serving repeated requests from a cache.
+type Chat struct {
+	layer
+	next chat.Client
+}
+
+var _ chat.Client = (*Chat)(nil)
+
+// NewChat returns a Chat caching the responses of next.
+func NewChat(next chat.Client, cfg Config) *Chat {
+	if cfg.Store == nil {
+		cfg.Store = NewLRU(DefaultMaxEntries)
+	}
+	c := &Chat{next: next}
+	c.init("exact", cfg)
+	return c
+}
+
+// key returns the cache key of req, or false if req is not cached.
+func (c *Chat) key(req *chat.ChatRequest) (string, bool) {
+	if req == nil || (c.cfg.Cacheable != nil && !c.cfg.Cacheable(req)) {
+		return "", false
+	}
+	k, err := ChatKey(req)
+	if err != nil {
+		c.fail(err)
+		return "", false
+	}
+	return k, true
+}
+
+// Create returns the cached response for req, or calls the API and caches
+// its response.
+func (c *Chat) Create(ctx context.Context, req *chat.ChatRequest) (*chat.ChatResponse, error) {
+	key, ok := c.key(req)
+	if !ok {
+		return c.next.Create(ctx, req)
+	}
+	ctl := controlFrom(ctx)
+	if !ctl.NoCache {
+		var resp chat.ChatResponse
+		if c.lookup(ctx, req.Model, key, &resp) {
+			return &resp, nil
+		}
+	}
+	resp, err := c.next.Create(ctx, req)
+	if err == nil && complete(resp) {
+		c.store(ctx, ctl, key, resp)
+	}
+	return resp, err
+}
+
+// CreateStream replays the cached response for req as a stream, or starts the
+// stream and caches the response it assembles once the stream completes.
+// Streams closed early or ending in an error are not cached.
+func (c *Chat) CreateStream(ctx context.Context, req *chat.ChatRequest, opts ...chat.StreamOption) (*chat.StreamReader, error) {
+	key, ok := c.key(req)
+	if !ok {
+		return c.next.CreateStream(ctx, req, opts...)
+	}
+	ctl := controlFrom(ctx)
+	if !ctl.NoCache {
+		var resp chat.ChatResponse
+		if c.lookup(ctx, req.Model, key, &resp) {
+			return chat.StreamResponse(&resp), nil
+		}
+	}
+	sr, err := c.next.CreateStream(ctx, req, opts...)
+	if err != nil || ctl.NoStore {
+		return sr, err
+	}
+	return recordStream(sr, func(resp *chat.ChatResponse) {
+		c.store(ctx, ctl, key, resp)
+	}), nil
+}
+
+// recordStream returns sr with save called on the assembled response once
+// the stream completes.
+func recordStream(sr *chat.StreamReader, save func(resp *chat.ChatResponse)) *chat.StreamReader {
+	var acc chat.Accumulator
+	return sr.Transform(func(chunk *chat.StreamChunk, err error) []*chat.StreamChunk {
+		if chunk != nil {
+			acc.Add(chunk)
+			return []*chat.StreamChunk{chunk}
+		}
+		if resp := acc.Response(); err == nil && complete(resp) {
+			save(resp)
+		}
+		return nil
+	})
+}
+
+// complete reports whether resp is worth caching: every choice finished
+// without an error.
+func complete(resp *chat.ChatResponse) bool {
+	if resp == nil || len(resp.Choices) == 0 {
+		return false
+	}
+	for _, ch := range resp.Choices {
+		if ch.Error != nil || ch.FinishReason == "" || ch.FinishReason == "error" {
+			return false
+		}
+	}
+	return true
+}
+
+// Embeddings is an embeddings.Client serving repeated requests from a cache.
+type Embeddings struct {
+	layer
+	next embeddings.Client
+}
+
+var _ embeddings.Client = (*Embeddings)(nil)
+
+// NewEmbeddings returns an Embeddings caching the responses of next.
+// Config.Cacheable does not apply.
+func NewEmbeddings(next embeddings.Client, cfg Config) *Embeddings {
+	if cfg.Store == nil {
+		cfg.Store = NewLRU(DefaultMaxEntries)
+	}
+	e := &Embeddings{next: next}
+	e.init("exact", cfg)
+	return e
+}
+
+// Create returns the cached embeddings for req, or calls the API and caches
+// its response.
+func (e *Embeddings) Create(ctx context.Context, req *embeddings.CreateRequest) (*embeddings.CreateResponse, error) {
+	if req == nil {
+		return e.next.Create(ctx, req)
+	}
+	key, err := EmbeddingsKey(req)
+	if err != nil {
+		e.fail(err)
+		return e.next.Create(ctx, req)
+	}
+	ctl := controlFrom(ctx)
+	if !ctl.NoCache {
+		var resp embeddings.CreateResponse
+		if e.lookup(ctx, req.Model, key, &resp) {
+			return &resp, nil
+		}
+	}
+	resp, err := e.next.Create(ctx, req)
+	if err == nil && resp != nil && len(resp.Data) > 0 {
+		e.store(ctx, ctl, key, resp)
+	}
+	return resp, err
+}
@@ -0,0 +1,163 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter"
+	"github.com/MetaDiv-AI/openrouter/cache"
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+	"github.com/MetaDiv-AI/openrouter/retry"
+)
+
+func TestChatKey(t *testing.T) {
+	temp := func(f float64) *float64 { return &f }
+	tool := func(params map[string]any) []chat.Tool {
+		return []chat.Tool{{Type: "function", Function: chat.FunctionDef{Name: "f", Parameters: params}}}
+	}
+	// Maps filled in a different order; encoding/json sorts the keys.
+	bias1, bias2 := map[int]float64{}, map[int]float64{}
+	for i := range 20 {
+		bias1[i] = float64(i)
+		bias2[19-i] = float64(19 - i)
+	}
+	base := chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+	with := func(f func(r *chat.ChatRequest)) *chat.ChatRequest {
+		r := base
+		f(&r)
+		return &r
+	}
+
+	tests := []struct {
+		name string
+		a, b *chat.ChatRequest
+		same bool
+	}{
+		{"identical", with(func(*chat.ChatRequest) {}), with(func(*chat.ChatRequest) {}), true},
+		{"stream ignored", with(func(r *chat.ChatRequest) { r.Stream = true }), &base, true},
+		{"map order", with(func(r *chat.ChatRequest) { r.LogitBias = bias1 }), with(func(r *chat.ChatRequest) { r.LogitBias = bias2 }), true},
+		{
+			"nested map order",
+			with(func(r *chat.ChatRequest) { r.Tools = tool(map[string]any{"type": "object", "required": []string{"x"}}) }),
+			with(func(r *chat.ChatRequest) { r.Tools = tool(map[string]any{"required": []string{"x"}, "type": "object"}) }),
+			true,
+		},
+		{"model", with(func(r *chat.ChatRequest) { r.Model = "other" }), &base, false},
+		{"temperature", with(func(r *chat.ChatRequest) { r.Temperature = temp(0) }), with(func(r *chat.ChatRequest) { r.Temperature = temp(1) }), false},
+		{"messages", with(func(r *chat.ChatRequest) { r.Messages = []chat.Message{{Role: "user", Content: "hello"}} }), &base, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := cache.ChatKey(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := cache.ChatKey(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (a == b) != tt.same {
+				t.Errorf("keys equal = %v, want %v", a == b, tt.same)
+			}
+		})
+	}
+	if base.Stream {
+		t.Error("ChatKey modified the request")
+	}
+}
+
+func TestChatControl(t *testing.T) {
+	tests := []struct {
+		name          string
+		ttl           time.Duration
+		first, second cache.Control
+		wait          time.Duration
+		calls         int
+		want          cache.Stats
+	}{
+		{name: "second call hits", calls: 1, want: cache.Stats{Hits: 1, Misses: 1}},
+		{name: "no cache skips the lookup", second: cache.Control{NoCache: true}, calls: 2, want: cache.Stats{Misses: 1}},
+		{name: "no store skips storing", first: cache.Control{NoStore: true}, calls: 2, want: cache.Stats{Misses: 2}},
+		{name: "no cache and no store", first: cache.Control{NoCache: true, NoStore: true}, calls: 2, want: cache.Stats{Misses: 1}},
+		{name: "live entry", ttl: time.Minute, wait: 30 * time.Millisecond, calls: 1, want: cache.Stats{Hits: 1, Misses: 1}},
+		{name: "expired entry", ttl: 20 * time.Millisecond, wait: 30 * time.Millisecond, calls: 2, want: cache.Stats{Misses: 2}},
+		{name: "per-call ttl", ttl: time.Minute, first: cache.Control{TTL: 20 * time.Millisecond}, wait: 30 * time.Millisecond, calls: 2, want: cache.Stats{Misses: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openroutertest.NewServer(t)
+			srv.Chat(openroutertest.Text("first"), openroutertest.Text("second"))
+			c := cache.NewChat(srv.Client().Chat, cache.Config{TTL: tt.ttl})
+			req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+
+			if _, err := c.Create(cache.WithControl(context.Background(), tt.first), req); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(tt.wait)
+			resp, err := c.Create(cache.WithControl(context.Background(), tt.second), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "first"
+			if tt.calls == 2 {
+				want = "second"
+			}
+			if got := resp.Choices[0].Message.Content; got != want {
+				t.Errorf("content = %q, want %q", got, want)
+			}
+			if got := c.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+			srv.AssertRequests(t, "/chat/completions", tt.calls)
+		})
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  openroutertest.Reply
+		read   int // chunks read before closing; -1 reads to the end
+		stored bool
+	}{
+		{"complete stream", openroutertest.Text("one two three"), -1, true},
+		{"closed early", openroutertest.Text("one two three").WithChunkDelay(20 * time.Millisecond), 1, false},
+		{"mid-stream error", openroutertest.MidStreamError("one two", 502, "provider failed"), -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openroutertest.NewServer(t)
+			srv.Chat(tt.reply, openroutertest.Text("fresh"))
+			noRetry := retry.DefaultPolicy()
+			noRetry.MaxAttempts = 1
+			c := cache.NewChat(srv.Client(openrouter.WithRetryPolicy(noRetry)).Chat, cache.Config{})
+			req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+
+			sr, err := c.CreateStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n := 0; tt.read < 0 || n < tt.read; n++ {
+				if _, err := sr.Next(); err != nil {
+					break
+				}
+			}
+			sr.Close()
+
+			// A stored stream answers the unary call; otherwise it reaches the API.
+			resp, err := c.Create(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "fresh"
+			if tt.stored {
+				want = "one two three"
+			}
+			if got := resp.Choices[0].Message.Content; got != want {
+				t.Errorf("content = %q, want %q", got, want)
+			}
+		})
+	}
+}
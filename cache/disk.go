@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk is a Store keeping one file per entry under a directory, so the cache
+// survives restarts. Each file starts with the entry's expiry time. Expired
+// entries are removed when read or by Prune.
+type Disk struct {
+	dir string
+}
+
+var _ Store = (*Disk)(nil)
+
+// NewDisk returns a Disk store in dir, creating it if needed.
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	return &Disk{dir: dir}, nil
+}
+
+// path returns the file for key. Keys are hashed so any string is a safe file
+// name, and sharded by their first byte to keep directories small.
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
+
+// Get implements Store.
+func (d *Disk) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cache: %w", err)
+	}
+	if len(data) < 8 || expired(data) {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+	return data[8:], true, nil
+}
+
+// Set implements Store. The file is written to a temporary name and renamed,
+// so concurrent readers never see a partial entry.
+func (d *Disk) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+	data := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), uint64(expires))
+	data = append(data, value...)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (d *Disk) Delete(_ context.Context, key string) error {
+	if err := os.Remove(d.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
+
+// Prune removes expired entries.
+func (d *Disk) Prune(ctx context.Context) error {
+	return filepath.WalkDir(d.dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		var header [8]byte
+		_, err = io.ReadFull(f, header[:])
+		f.Close()
+		if err == nil && expired(header[:]) {
+			_ = os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// expired reports whether the expiry time at the start of data has passed.
+func expired(data []byte) bool {
+	expires := int64(binary.BigEndian.Uint64(data))
+	return expires != 0 && time.Now().UnixNano() > expires
+}
@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/embeddings"
+	"github.com/MetaDiv-AI/openrouter/metrics"
+)
+
+// DefaultThreshold is the cosine similarity above which Semantic treats two
+// prompts as the same.
+const DefaultThreshold = 0.95
+
+// SemanticConfig configures a Semantic cache.
+type SemanticConfig struct {
+	// Embeddings embeds prompts, e.g. client.Embeddings. Required.
+	Embeddings embeddings.Client
+	// Model is the embedding model. Required.
+	Model string
+	// Threshold is the minimum cosine similarity for a hit. Zero uses
+	// DefaultThreshold.
+	Threshold float64
+	// Store holds the entries. Nil uses a MemoryVectors of DefaultMaxEntries.
+	Store VectorStore
+	// TTL is how long entries are kept. Zero keeps them until evicted.
+	TTL time.Duration
+	// Cacheable, if set, selects the requests to cache. Others go straight to
+	// the API.
+	Cacheable func(req *chat.ChatRequest) bool
+	// OnError, if set, is called with embedding, store and encoding errors.
+	OnError func(err error)
+	// Metrics, if set, records lookups as LookupsMetric.
+	Metrics metrics.Provider
+}
+
+// Semantic is a chat.Client that answers a prompt from the cache when a
+// similar enough one was answered before. It embeds the last user message
+// and searches the entries with the same model, embedding model, system
+// prompt, request parameters and earlier turns: in a multi-turn conversation only the last
+// user message may differ, and the rest must match exactly.
+//
+// Each lookup costs an embeddings request. Requests whose last user message
+// is not plain text are not cached.
+type Semantic struct {
+	layer
+	next  chat.Client
+	embed embeddings.Client
+	model string
+	min   float64
+	store VectorStore
+}
+
+var _ chat.Client = (*Semantic)(nil)
+
+// NewSemantic returns a Semantic caching the responses of next.
+func NewSemantic(next chat.Client, cfg SemanticConfig) *Semantic {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryVectors(DefaultMaxEntries)
+	}
+	s := &Semantic{
+		next:  next,
+		embed: cfg.Embeddings,
+		model: cfg.Model,
+		min:   cfg.Threshold,
+		store: cfg.Store,
+	}
+	s.init("semantic", Config{TTL: cfg.TTL, Cacheable: cfg.Cacheable, OnError: cfg.OnError, Metrics: cfg.Metrics})
+	return s
+}
+
+// query is an embedded prompt.
+type query struct {
+	scope  string
+	vector []float32
+}
+
+// query embeds the last user message of req, or returns false if req is not
+// cached.
+func (s *Semantic) query(ctx context.Context, req *chat.ChatRequest) (query, bool) {
+	if req == nil || (s.cfg.Cacheable != nil && !s.cfg.Cacheable(req)) {
+		return query{}, false
+	}
+	text, ok := lastUserText(req.Messages)
+	if !ok {
+		return query{}, false
+	}
+	scope, err := semanticScope(req, s.model)
+	if err != nil {
+		s.fail(err)
+		return query{}, false
+	}
+	resp, err := s.embed.Create(ctx, &embeddings.CreateRequest{Model: s.model, Input: text})
+	if err != nil {
+		s.fail(err)
+		return query{}, false
+	}
+	if len(resp.Data) == 0 {
+		s.fail(errors.New("cache: embeddings response has no data"))
+		return query{}, false
+	}
+	vector := normalize(resp.Data[0].Embedding)
+	if vector == nil {
+		return query{}, false
+	}
+	return query{scope: scope, vector: vector}, true
+}
+
+// search returns the cached response for q, counting the result. It returns
+// an error if the store failed, in which case the response is not added:
+// the store cannot tell whether it already holds a match.
+func (s *Semantic) search(ctx context.Context, model string, q query) (*chat.ChatResponse, bool, error) {
+	m, ok, err := s.store.Search(ctx, q.scope, q.vector, s.min)
+	if err != nil {
+		s.fail(err)
+		s.count(model, resultError)
+		return nil, false, err
+	}
+	var resp chat.ChatResponse
+	if ok {
+		if err = json.Unmarshal(m.Entry.Value, &resp); err != nil {
+			s.fail(err)
+			ok = false
+		}
+	}
+	s.count(model, hitOrMiss(ok))
+	return &resp, ok, nil
+}
+
+// add stores resp for q unless ctl says otherwise.
+func (s *Semantic) add(ctx context.Context, ctl Control, q query, resp *chat.ChatResponse) {
+	if ctl.NoStore {
+		return
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	entry := VectorEntry{Scope: q.scope, Vector: q.vector, Value: data}
+	ttl := s.cfg.TTL
+	if ctl.TTL > 0 {
+		ttl = ctl.TTL
+	}
+	if ttl > 0 {
+		entry.Expires = time.Now().Add(ttl)
+	}
+	if err := s.store.Add(context.WithoutCancel(ctx), entry); err != nil {
+		s.fail(err)
+	}
+}
+
+// Create returns a cached response to a similar prompt, or calls the API and
+// caches its response.
+func (s *Semantic) Create(ctx context.Context, req *chat.ChatRequest) (*chat.ChatResponse, error) {
+	ctl := controlFrom(ctx)
+	if ctl.NoCache && ctl.NoStore {
+		return s.next.Create(ctx, req)
+	}
+	q, ok := s.query(ctx, req)
+	if !ok {
+		return s.next.Create(ctx, req)
+	}
+	if !ctl.NoCache {
+		resp, ok, err := s.search(ctx, req.Model, q)
+		if ok {
+			return resp, nil
+		}
+		ctl.NoStore = ctl.NoStore || err != nil
+	}
+	resp, err := s.next.Create(ctx, req)
+	if err == nil && complete(resp) {
+		s.add(ctx, ctl, q, resp)
+	}
+	return resp, err
+}
+
+// CreateStream replays a cached response to a similar prompt as a stream, or
+// starts the stream and caches the response it assembles once the stream
+// completes.
+func (s *Semantic) CreateStream(ctx context.Context, req *chat.ChatRequest, opts ...chat.StreamOption) (*chat.StreamReader, error) {
+	ctl := controlFrom(ctx)
+	if ctl.NoCache && ctl.NoStore {
+		return s.next.CreateStream(ctx, req, opts...)
+	}
+	q, ok := s.query(ctx, req)
+	if !ok {
+		return s.next.CreateStream(ctx, req, opts...)
+	}
+	if !ctl.NoCache {
+		resp, ok, err := s.search(ctx, req.Model, q)
+		if ok {
+			return chat.StreamResponse(resp), nil
+		}
+		ctl.NoStore = ctl.NoStore || err != nil
+	}
+	sr, err := s.next.CreateStream(ctx, req, opts...)
+	if err != nil || ctl.NoStore {
+		return sr, err
+	}
+	return recordStream(sr, func(resp *chat.ChatResponse) {
+		s.add(ctx, ctl, q, resp)
+	}), nil
+}
+
+// lastUserText returns the text of the last user message, or false if there
+// is none or it has non-text parts.
+func lastUserText(messages []chat.Message) (string, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		text, ok := contentText(messages[i].Content)
+		return text, ok && text != ""
+	}
+	return "", false
+}
+
+// contentText returns the text of message content, or false if it has
+// non-text parts.
+func contentText(content any) (string, bool) {
+	switch content := content.(type) {
+	case string:
+		return content, true
+	case []chat.ContentPart:
+		texts := make([]string, 0, len(content))
+		for _, p := range content {
+			if p.Type != "text" {
+				return "", false
+			}
+			texts = append(texts, p.Text)
+		}
+		return strings.Join(texts, "\n"), true
+	}
+	return "", false
+}
+
+// semanticScope hashes what must match for a cached response to answer req:
+// the embedding model, the system prompt, the earlier turns of the
+// conversation and every request field other than the messages. Only the last
+// user message is left out, to be compared by similarity.
+func semanticScope(req *chat.ChatRequest, embeddingModel string) (string, error) {
+	last := -1
+	for i, m := range req.Messages {
+		if m.Role == "user" {
+			last = i
+		}
+	}
+	var system []any
+	var history []chat.Message
+	for i, m := range req.Messages {
+		switch {
+		case m.Role == "system" || m.Role == "developer":
+			system = append(system, m.Content)
+		case i != last:
+			history = append(history, m)
+		}
+	}
+	r := *req
+	r.Messages, r.Stream = nil, false
+	data, err := json.Marshal(struct {
+		Embedding string            `json:"embedding"`
+		Request   *chat.ChatRequest `json:"request"`
+		System    []any             `json:"system"`
+		History   []chat.Message    `json:"history"`
+	}{embeddingModel, &r, system, history})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(keyVersion+"\x00semantic\x00"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,73 @@
+package cache_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/MetaDiv-AI/openrouter/cache"
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+)
+
+// failingVectors is a VectorStore whose searches fail.
+type failingVectors struct{ adds int }
+
+func (f *failingVectors) Search(context.Context, string, []float32, float64) (cache.VectorMatch, bool, error) {
+	return cache.VectorMatch{}, false, stderrors.New("vector store down")
+}
+
+func (f *failingVectors) Add(context.Context, cache.VectorEntry) error {
+	f.adds++
+	return nil
+}
+
+func TestSemantic(t *testing.T) {
+	vector := []float32{0.6, 0.8, 0}
+	req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "What is Go?"}}}
+	shared := cache.NewMemoryVectors(10)
+
+	tests := []struct {
+		name  string
+		model string
+		store cache.VectorStore
+		want  cache.Stats
+		calls int // chat requests reaching the API over two identical calls
+	}{
+		{"second call hits", "embed-a", shared, cache.Stats{Hits: 1, Misses: 1}, 1},
+		{"other embedding model misses", "embed-b", shared, cache.Stats{Hits: 1, Misses: 1}, 1},
+		{"store errors are not misses", "embed-a", &failingVectors{}, cache.Stats{Errors: 2}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openroutertest.NewServer(t)
+			client := srv.Client()
+			srv.Embeddings(openroutertest.Embedding(vector), openroutertest.Embedding(vector))
+			srv.Chat(openroutertest.Text("first"), openroutertest.Text("second"))
+			var errs []error
+			s := cache.NewSemantic(client.Chat, cache.SemanticConfig{
+				Embeddings: client.Embeddings,
+				Model:      tt.model,
+				Store:      tt.store,
+				OnError:    func(err error) { errs = append(errs, err) },
+			})
+			for range 2 {
+				if _, err := s.Create(context.Background(), req); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := s.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+			srv.AssertRequests(t, "/chat/completions", tt.calls)
+			if f, ok := tt.store.(*failingVectors); ok {
+				if f.adds != 0 {
+					t.Errorf("added %d entries after failed searches", f.adds)
+				}
+				if len(errs) != 2 {
+					t.Errorf("OnError called %d times, want 2", len(errs))
+				}
+			}
+		})
+	}
+}
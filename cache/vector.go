@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// VectorStore holds embedded prompts and their responses for Semantic.
+// Implementations must be safe for concurrent use.
+type VectorStore interface {
+	// Search returns the live entry in scope most similar to vector, if its
+	// cosine similarity is at least minScore. Vectors are normalized to unit
+	// length, so similarity is their dot product.
+	Search(ctx context.Context, scope string, vector []float32, minScore float64) (VectorMatch, bool, error)
+	// Add stores entry.
+	Add(ctx context.Context, entry VectorEntry) error
+}
+
+// VectorEntry is a stored prompt embedding and the encoded response to it.
+type VectorEntry struct {
+	// Scope groups entries that may answer each other: Semantic uses the
+	// model, embedding model, system prompt and earlier turns of the
+	// conversation.
+	Scope string
+	// Vector is the unit-length prompt embedding.
+	Vector []float32
+	// Value is the encoded response.
+	Value []byte
+	// Expires is when the entry stops matching. Zero means never.
+	Expires time.Time
+}
+
+// VectorMatch is a VectorStore search result.
+type VectorMatch struct {
+	Entry VectorEntry
+	// Score is the cosine similarity to the query.
+	Score float64
+}
+
+// MemoryVectors is an in-memory VectorStore searched linearly. Once full it
+// drops the oldest entry for each one added.
+type MemoryVectors struct {
+	mu      sync.RWMutex
+	max     int
+	entries []VectorEntry
+}
+
+var _ VectorStore = (*MemoryVectors)(nil)
+
+// NewMemoryVectors returns a MemoryVectors holding up to maxEntries entries,
+// or DefaultMaxEntries if maxEntries is not positive.
+func NewMemoryVectors(maxEntries int) *MemoryVectors {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &MemoryVectors{max: maxEntries}
+}
+
+// Search implements VectorStore.
+func (m *MemoryVectors) Search(_ context.Context, scope string, vector []float32, minScore float64) (VectorMatch, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := time.Now()
+	var best VectorMatch
+	found := false
+	for _, e := range m.entries {
+		if e.Scope != scope || (!e.Expires.IsZero() && now.After(e.Expires)) {
+			continue
+		}
+		score := dot(e.Vector, vector)
+		if score >= minScore && (!found || score > best.Score) {
+			best, found = VectorMatch{Entry: e, Score: score}, true
+		}
+	}
+	return best, found, nil
+}
+
+// Add implements VectorStore.
+func (m *MemoryVectors) Add(_ context.Context, entry VectorEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	live := m.entries[:0]
+	for _, e := range m.entries {
+		if e.Expires.IsZero() || now.Before(e.Expires) {
+			live = append(live, e)
+		}
+	}
+	clear(m.entries[len(live):])
+	m.entries = append(live, entry)
+	if n := len(m.entries) - m.max; n > 0 {
+		clear(m.entries[:n])
+		m.entries = m.entries[n:]
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet dropped.
+func (m *MemoryVectors) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// normalize returns a unit-length copy of v, or nil if v is zero.
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
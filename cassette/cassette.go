@@ -0,0 +1,404 @@
+// Package cassette records OpenRouter HTTP traffic to a file and replays it
+// offline, so integration tests exercise the real request, parsing and
+// streaming paths without network access.
+//
+//	rec, err := cassette.New("testdata/chat.json", cassette.ModeAuto)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//	client, _ := openrouter.NewClient(
+//	    openrouter.WithAPIKey(os.Getenv("OPENROUTER_API_KEY")),
+//	    openrouter.WithTransport(rec),
+//	)
+//
+// Credentials are scrubbed before anything is written. Streamed responses are
+// stored chunk by chunk with their timing. Requests that fail without a
+// response, such as connection errors, are not recorded.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode selects whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the
+	// network. Unmatched requests fail with ErrNoInteraction.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests upstream and overwrites the cassette on Stop.
+	ModeRecord
+	// ModeAuto replays if the cassette file exists and records otherwise.
+	ModeAuto
+)
+
+// ErrNoInteraction is returned in replay mode for a request the cassette has
+// no unused recording of.
+var ErrNoInteraction = stderrors.New("cassette: no matching interaction")
+
+// Version is the cassette file format version.
+const Version = 1
+
+// Cassette is the file format: the recorded interactions in request order.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. The URL keeps only the path and query.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response. Streamed (text/event-stream) bodies are
+// stored in Chunks, others in Body.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	Chunks []Chunk     `json:"chunks,omitempty"`
+}
+
+// Chunk is a piece of a streamed body and the time since the previous piece
+// (or since the response headers, for the first).
+type Chunk struct {
+	Delay time.Duration `json:"delay"`
+	Data  string        `json:"data"`
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to reach the real API when
+// recording; http.DefaultTransport by default.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.upstream = rt
+	}
+}
+
+// WithTiming replays streamed chunks with their recorded delays multiplied by
+// scale: 1 for real time, 0 (the default) for no delays.
+func WithTiming(scale float64) Option {
+	return func(r *Recorder) {
+		r.timing = scale
+	}
+}
+
+// IgnoreFields leaves the given top-level JSON body fields (e.g. "user" or
+// "seed") out of request matching.
+func IgnoreFields(fields ...string) Option {
+	return func(r *Recorder) {
+		r.ignore = append(r.ignore, fields...)
+	}
+}
+
+// ScrubHeaders replaces the values of extra request headers with
+// "[REDACTED]" when recording. Authorization is always scrubbed.
+func ScrubHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrub = append(r.scrub, names...)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette.
+type Recorder struct {
+	path     string
+	mode     Mode
+	upstream http.RoundTripper
+	timing   float64
+	ignore   []string
+	scrub    []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New returns a Recorder for the cassette at path. In ModeAuto the mode is
+// resolved here: replay if path exists, record otherwise.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		upstream: http.DefaultTransport,
+		scrub:    []string{"Authorization"},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: decode %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	r.cassette.Version = Version
+	return r, nil
+}
+
+// Mode returns the resolved mode, ModeReplay or ModeRecord.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Stop writes the cassette when recording. Call it after the last response
+// body has been read or closed: a body that is still open is recorded empty.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	key := r.key(req.Method, pathOf(req), body)
+	r.mu.Lock()
+	var found *Interaction
+	for i := range r.cassette.Interactions {
+		in := &r.cassette.Interactions[i]
+		if !r.used[i] && r.key(in.Request.Method, in.Request.URL, []byte(in.Request.Body)) == key {
+			r.used[i] = true
+			found = in
+			break
+		}
+	}
+	r.mu.Unlock()
+	if found == nil {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, pathOf(req))
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", found.Response.Status, http.StatusText(found.Response.Status)),
+		StatusCode:    found.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        found.Response.Header.Clone(),
+		ContentLength: -1,
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	resp.Header.Del("Content-Length")
+	if found.Response.Chunks != nil {
+		resp.Body = &replayBody{req: req, chunks: found.Response.Chunks, scale: r.timing}
+	} else {
+		resp.Body = io.NopCloser(strings.NewReader(found.Response.Body))
+	}
+	return resp, nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	header := req.Header.Clone()
+	for _, name := range r.scrub {
+		if header.Get(name) != "" {
+			header.Set(name, "[REDACTED]")
+		}
+	}
+
+	// A request that got no response is not recorded: replaying it would
+	// need the original error, and the retry it caused is recorded anyway.
+	resp, err := r.upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// The status and headers are recorded now; the body once it has been
+	// read or closed.
+	respHeader := resp.Header.Clone()
+	respHeader.Del("Set-Cookie")
+	r.mu.Lock()
+	idx := len(r.cassette.Interactions)
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: pathOf(req), Header: header, Body: string(body)},
+		Response: Response{Status: resp.StatusCode, Header: respHeader},
+	})
+	r.mu.Unlock()
+
+	streamed := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	resp.Body = &recordBody{
+		ReadCloser: resp.Body,
+		last:       time.Now(),
+		done: func(chunks []Chunk) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			rec := &r.cassette.Interactions[idx].Response
+			if streamed {
+				rec.Chunks = append([]Chunk{}, chunks...)
+			} else {
+				var b strings.Builder
+				for _, c := range chunks {
+					b.WriteString(c.Data)
+				}
+				rec.Body = b.String()
+			}
+		},
+	}
+	return resp, nil
+}
+
+// key identifies a request for matching: method, path and canonical body.
+func (r *Recorder) key(method, path string, body []byte) string {
+	return method + " " + path + " " + r.normalize(body)
+}
+
+// normalize re-encodes a JSON body with sorted keys and without ignored
+// fields. Non-JSON bodies are compared as is.
+func (r *Recorder) normalize(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	if m, ok := v.(map[string]any); ok {
+		for _, f := range r.ignore {
+			delete(m, f)
+		}
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// readBody reads the request body and restores it for the real transport.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func pathOf(req *http.Request) string {
+	return req.URL.RequestURI()
+}
+
+// recordBody captures a response body as it is read.
+type recordBody struct {
+	io.ReadCloser
+	last   time.Time
+	chunks []Chunk
+	done   func([]Chunk)
+	once   sync.Once
+}
+
+func (b *recordBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		now := time.Now()
+		b.chunks = append(b.chunks, Chunk{Delay: now.Sub(b.last), Data: string(p[:n])})
+		b.last = now
+	}
+	if err != nil {
+		b.once.Do(func() { b.done(b.chunks) })
+	}
+	return n, err
+}
+
+func (b *recordBody) Close() error {
+	b.once.Do(func() { b.done(b.chunks) })
+	return b.ReadCloser.Close()
+}
+
+// replayBody serves recorded chunks, optionally with their delays.
+type replayBody struct {
+	req    *http.Request
+	chunks []Chunk
+	scale  float64
+	cur    []byte
+	closed bool
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	if b.closed {
+		return 0, stderrors.New("cassette: read on closed body")
+	}
+	for len(b.cur) == 0 {
+		if len(b.chunks) == 0 {
+			return 0, io.EOF
+		}
+		c := b.chunks[0]
+		b.chunks = b.chunks[1:]
+		if d := time.Duration(float64(c.Delay) * b.scale); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-t.C:
+			case <-b.req.Context().Done():
+				t.Stop()
+				return 0, b.req.Context().Err()
+			}
+		}
+		b.cur = []byte(c.Data)
+	}
+	n := copy(p, b.cur)
+	b.cur = b.cur[n:]
+	return n, nil
+}
+
+func (b *replayBody) Close() error {
+	b.closed = true
+	return nil
+}
@@ -0,0 +1,124 @@
+package cassette_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MetaDiv-AI/openrouter/cassette"
+)
+
+func TestRecordReplay(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"n\":1}\n\n"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("data: [DONE]\n\n"))
+		case "/missing":
+			w.Header().Set("Set-Cookie", "session=secret")
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	send := func(rt http.RoundTripper, urlPath, body string, read bool) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, upstream.URL+urlPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer sk-secret")
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !read {
+			return resp, ""
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(data)
+	}
+
+	rec, err := cassette.New(path, cassette.ModeAuto, cassette.WithTransport(upstream.Client().Transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != cassette.ModeRecord {
+		t.Fatalf("mode = %v, want record", rec.Mode())
+	}
+	send(rec, "/json", `{"b":2,"a":1}`, true)
+	send(rec, "/stream", `{}`, true)
+	send(rec, "/missing", `{}`, true)
+	open, _ := send(rec, "/json", `{"undrained":true}`, false)
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	open.Body.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-secret") || strings.Contains(string(data), "session=secret") {
+		t.Errorf("cassette leaks credentials:\n%s", data)
+	}
+	var c cassette.Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := []int{200, 200, 404, 200}
+	if len(c.Interactions) != len(wantStatus) {
+		t.Fatalf("recorded %d interactions, want %d", len(c.Interactions), len(wantStatus))
+	}
+	for i, in := range c.Interactions {
+		if in.Response.Status != wantStatus[i] {
+			t.Errorf("interaction %d: status %d, want %d", i, in.Response.Status, wantStatus[i])
+		}
+		if in.Response.Header.Get("Content-Type") == "" {
+			t.Errorf("interaction %d: no response headers", i)
+		}
+	}
+	if n := len(c.Interactions[1].Response.Chunks); n == 0 {
+		t.Error("stream recorded without chunks")
+	}
+
+	// Replay matches on the canonical body and serves the recordings in order.
+	play, err := cassette.New(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if play.Mode() != cassette.ModeReplay {
+		t.Fatalf("mode = %v, want replay", play.Mode())
+	}
+	tests := []struct {
+		path, body string
+		status     int
+		want       string
+	}{
+		{"/stream", `{}`, 200, "data: {\"n\":1}\n\ndata: [DONE]\n\n"},
+		{"/json", `{"a":1,"b":2}`, 200, `{"ok":true}`},
+		{"/missing", `{}`, 404, "not found\n"},
+	}
+	for _, tt := range tests {
+		resp, got := send(play, tt.path, tt.body, true)
+		if resp.StatusCode != tt.status || got != tt.want {
+			t.Errorf("replay %s = %d %q, want %d %q", tt.path, resp.StatusCode, got, tt.status, tt.want)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodPost, upstream.URL+"/json", strings.NewReader(`{"a":1,"b":2}`))
+	if _, err := play.RoundTrip(req); err == nil {
+		t.Error("replayed a used interaction twice")
+	}
+}
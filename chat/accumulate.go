@@ -0,0 +1,135 @@
+package chat
+
+import "sort"
+
+// Accumulator assembles stream chunks into the ChatResponse the request would
+// have returned unstreamed: content, reasoning and tool calls are joined per
+// choice, and the usage is taken from the final chunk. The zero value is
+// ready to use.
+type Accumulator struct {
+	resp    ChatResponse
+	choices map[int]*accumulatedChoice
+	calls   *toolCallAccumulator
+}
+
+type accumulatedChoice struct {
+	role, content, reasoning string
+	finishReason             string
+	err                      *ChoiceError
+}
+
+// Add folds chunk into the response.
+func (a *Accumulator) Add(chunk *StreamChunk) {
+	if a.choices == nil {
+		a.choices = make(map[int]*accumulatedChoice)
+		a.calls = newToolCallAccumulator()
+	}
+	if chunk.ID != "" {
+		a.resp.ID = chunk.ID
+	}
+	if chunk.Created != 0 {
+		a.resp.Created = chunk.Created
+	}
+	if chunk.Model != "" {
+		a.resp.Model = chunk.Model
+	}
+	if chunk.Provider != "" {
+		a.resp.Provider = chunk.Provider
+	}
+	if chunk.Usage != nil {
+		u := *chunk.Usage
+		a.resp.Usage = &u
+	}
+	for _, c := range chunk.Choices {
+		ac := a.choices[c.Index]
+		if ac == nil {
+			ac = &accumulatedChoice{}
+			a.choices[c.Index] = ac
+		}
+		if d := c.Delta; d != nil {
+			if d.Role != "" {
+				ac.role = d.Role
+			}
+			if s, ok := d.Content.(string); ok {
+				ac.content += s
+			}
+			ac.reasoning += d.Reasoning
+			if len(d.ToolCalls) > 0 {
+				a.calls.add(c.Index, d.ToolCalls)
+			}
+		}
+		if c.FinishReason != "" {
+			ac.finishReason = c.FinishReason
+		}
+		if c.Error != nil {
+			ac.err = c.Error
+		}
+	}
+}
+
+// Response returns the response assembled so far.
+func (a *Accumulator) Response() *ChatResponse {
+	resp := a.resp
+	resp.Object = "chat.completion"
+	resp.Choices = make([]Choice, 0, len(a.choices))
+	for index, ac := range a.choices {
+		role := ac.role
+		if role == "" {
+			role = "assistant"
+		}
+		msg := &Message{Role: role, Content: ac.content, Reasoning: ac.reasoning}
+		if calls, ok := a.calls.choices[index]; ok {
+			for _, tc := range calls {
+				msg.ToolCalls = append(msg.ToolCalls, *tc)
+			}
+			sort.Slice(msg.ToolCalls, func(i, j int) bool { return *msg.ToolCalls[i].Index < *msg.ToolCalls[j].Index })
+		}
+		resp.Choices = append(resp.Choices, Choice{Index: index, Message: msg, FinishReason: ac.finishReason, Error: ac.err})
+	}
+	sort.Slice(resp.Choices, func(i, j int) bool { return resp.Choices[i].Index < resp.Choices[j].Index })
+	return &resp
+}
+
+// StreamResponse returns a finished StreamReader replaying resp as a stream:
+// one chunk per choice with its whole message, a finish chunk per choice and,
+// if resp has usage, a usage-only chunk. Use it to serve stored or synthetic
+// responses through the streaming API.
+func StreamResponse(resp *ChatResponse) *StreamReader {
+	chunks := make([]StreamChunk, 0, 2*len(resp.Choices)+1)
+	chunk := func(choices []Choice, usage *Usage) StreamChunk {
+		return StreamChunk{
+			ID:       resp.ID,
+			Object:   "chat.completion.chunk",
+			Created:  resp.Created,
+			Model:    resp.Model,
+			Provider: resp.Provider,
+			Choices:  choices,
+			Usage:    usage,
+		}
+	}
+	for _, c := range resp.Choices {
+		if m := c.Message; m != nil {
+			delta := &Message{Role: m.Role, Content: m.Content, Reasoning: m.Reasoning}
+			for i, tc := range m.ToolCalls {
+				if tc.Index == nil {
+					n := i
+					tc.Index = &n
+				}
+				delta.ToolCalls = append(delta.ToolCalls, tc)
+			}
+			chunks = append(chunks, chunk([]Choice{{Index: c.Index, Delta: delta}}, nil))
+		}
+		chunks = append(chunks, chunk([]Choice{{Index: c.Index, Delta: &Message{}, FinishReason: c.FinishReason, Error: c.Error}}, nil))
+	}
+	if resp.Usage != nil {
+		u := *resp.Usage
+		chunks = append(chunks, chunk([]Choice{}, &u))
+	}
+
+	sr := newStreamReader(max(len(chunks), 1), BackpressureBlock)
+	for i := range chunks {
+		sr.push(streamItem{chunk: chunks[i]}, BackpressureBlock)
+	}
+	sr.finish()
+	return sr
+}
@@ -0,0 +1,26 @@
+package chat
+
+// Backpressure selects what happens when a stream consumer falls behind and
+// its buffer is full.
+type Backpressure int
+
+const (
+	// BackpressureBlock makes the producer wait for the consumer.
+	BackpressureBlock Backpressure = iota
+	// BackpressureDropOldest discards the oldest buffered chunk to make room.
+	BackpressureDropOldest
+	// BackpressureFail ends the consumer's stream with errors.ErrSlowConsumer.
+	BackpressureFail
+)
+
+// String returns the policy name.
+func (b Backpressure) String() string {
+	switch b {
+	case BackpressureDropOldest:
+		return "drop-oldest"
+	case BackpressureFail:
+		return "fail"
+	default:
+		return "block"
+	}
+}
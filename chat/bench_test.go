@@ -0,0 +1,159 @@
+package chat_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/MetaDiv-AI/http_caller"
+	"github.com/MetaDiv-AI/openrouter"
+	"github.com/MetaDiv-AI/openrouter/chat"
+)
+
+// Run with go test -bench . -benchmem ./chat to compare the allocations of
+// the streaming paths. Each op reads a whole stream of benchChunks chunks.
+const benchChunks = 100
+
+var benchRequest = &chat.ChatRequest{
+	Model:    "openai/gpt-4o",
+	Messages: []chat.Message{{Role: "user", Content: "Count to one hundred."}},
+}
+
+// streamBody returns an SSE chat stream of n content chunks followed by a
+// finish chunk, a usage chunk and [DONE].
+func streamBody(tb testing.TB, n int) []byte {
+	var buf bytes.Buffer
+	write := func(chunk chat.StreamChunk) {
+		chunk.ID, chunk.Object, chunk.Model = "gen-bench", "chat.completion.chunk", benchRequest.Model
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		buf.WriteString("data: ")
+		buf.Write(data)
+		buf.WriteString("\n\n")
+	}
+	buf.WriteString(": OPENROUTER PROCESSING\n\n")
+	for range n {
+		write(chat.StreamChunk{Choices: []chat.Choice{{Delta: &chat.Message{Role: "assistant", Content: "token "}}}})
+	}
+	write(chat.StreamChunk{Choices: []chat.Choice{{Delta: &chat.Message{}, FinishReason: "stop"}}})
+	write(chat.StreamChunk{Choices: []chat.Choice{}, Usage: &chat.Usage{PromptTokens: 12, CompletionTokens: n, TotalTokens: n + 12}})
+	buf.WriteString("data: [DONE]\n\n")
+	return buf.Bytes()
+}
+
+// bodyTransport answers every request with the same event stream, so the
+// benchmarks measure decoding rather than the network.
+type bodyTransport []byte
+
+func (t bodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       io.NopCloser(bytes.NewReader(t)),
+		Request:    req,
+	}, nil
+}
+
+func benchClient(b *testing.B, body []byte) *openrouter.Client {
+	client, err := openrouter.NewClient(
+		openrouter.WithAPIKey("sk-bench"),
+		openrouter.WithBaseURL("http://openrouter.test/api/v1"),
+		openrouter.WithTransport(bodyTransport(body)),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return client
+}
+
+// BenchmarkProcessLine is the line-based path: http_caller's ChunkHandler
+// feeding StreamReader.ProcessLine, which decodes every line into a new chunk.
+func BenchmarkProcessLine(b *testing.B) {
+	body := streamBody(b, benchChunks)
+	httpClient := &http.Client{Transport: bodyTransport(body)}
+	ctx := context.Background()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sr := chat.NewStreamReader()
+		go func() {
+			err := http_caller.New[chat.ChatRequest, json.RawMessage]("http://openrouter.test/api/v1/chat/completions").
+				WithClient(httpClient).
+				Header("Authorization", "Bearer sk-bench").
+				Body(benchRequest).
+				StreamPost(ctx, sr.ProcessLine)
+			if err != nil {
+				sr.SetError(err)
+			}
+		}()
+		drain(b, sr)
+	}
+}
+
+// BenchmarkCreateStream is the channel-based path used by CreateStream.
+func BenchmarkCreateStream(b *testing.B) {
+	body := streamBody(b, benchChunks)
+	client := benchClient(b, body)
+	ctx := context.Background()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sr, err := client.Chat.CreateStream(ctx, benchRequest)
+		if err != nil {
+			b.Fatal(err)
+		}
+		drain(b, sr)
+	}
+}
+
+// BenchmarkCreateStreamFunc is the callback path: pooled decoders and a
+// reused chunk, delivered on the calling goroutine. The strings json decodes
+// into each chunk are still allocated.
+func BenchmarkCreateStreamFunc(b *testing.B) {
+	body := streamBody(b, benchChunks)
+	client := benchClient(b, body)
+	ctx := context.Background()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		err := client.Chat.CreateStreamFunc(ctx, benchRequest, func(*chat.StreamChunk) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n != benchChunks+2 {
+			b.Fatalf("got %d chunks, want %d", n, benchChunks+2)
+		}
+	}
+}
+
+// drain reads sr to the end, checking every chunk arrived.
+func drain(b *testing.B, sr *chat.StreamReader) {
+	defer sr.Close()
+	n := 0
+	for {
+		_, err := sr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+		n++
+	}
+	if n != benchChunks+2 {
+		b.Fatalf("got %d chunks, want %d", n, benchChunks+2)
+	}
+}
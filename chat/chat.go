@@ -2,18 +2,34 @@ package chat
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/MetaDiv-AI/openrouter/internal"
+	"github.com/MetaDiv-AI/openrouter/observe"
+	"github.com/MetaDiv-AI/openrouter/sse"
 )
 
+// Client is the chat completion API. *Service implements it; depend on Client
+// to substitute a fake in tests (NewStreamReader, ProcessLine and SetError
+// build a StreamReader without a server).
+type Client interface {
+	Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error)
+	CreateStream(ctx context.Context, req *ChatRequest, opts ...StreamOption) (*StreamReader, error)
+}
+
+var _ Client = (*Service)(nil)
+
 // Service provides chat completion operations.
 type Service struct {
-	caller *internal.Caller
+	caller         *internal.Caller
+	streamDefaults []StreamOption
 }
 
-// NewService creates a new chat service.
-func NewService(caller *internal.Caller) *Service {
-	return &Service{caller: caller}
+// NewService creates a new chat service. streamDefaults are applied to every
+// CreateStream call before the call's own options.
+func NewService(caller *internal.Caller, streamDefaults ...StreamOption) *Service {
+	return &Service{caller: caller, streamDefaults: streamDefaults}
 }
 
 // Create sends a non-streaming chat completion request.
@@ -22,31 +38,149 @@ func (s *Service) Create(ctx context.Context, req *ChatRequest) (*ChatResponse,
 		req = &ChatRequest{}
 	}
 	req.Stream = false
+	ctx = withRequestInfo(ctx, req)
+	ctx, op := s.caller.StartOp(ctx, observe.Operation{Name: observe.OpChat, Endpoint: "/chat/completions", Model: req.Model, Request: req})
 
 	var resp ChatResponse
 	if err := s.caller.DoPost(ctx, "/chat/completions", req, &resp); err != nil {
+		op.End(observe.Result{Err: err})
 		return nil, err
 	}
+	op.End(resp.result())
 	return &resp, nil
 }
 
-// CreateStream sends a streaming chat completion request.
-func (s *Service) CreateStream(ctx context.Context, req *ChatRequest) (*StreamReader, error) {
+// CreateStream sends a streaming chat completion request. The connection is
+// retried under the client's retry policy until the first chunk arrives.
+// Closing the returned reader aborts the request.
+func (s *Service) CreateStream(ctx context.Context, req *ChatRequest, opts ...StreamOption) (*StreamReader, error) {
 	if req == nil {
 		req = &ChatRequest{}
 	}
 	req.Stream = true
+	o := s.streamOptions(opts)
 
-	sr := NewStreamReader()
-	go func() {
-		err := s.caller.DoStreamPost(ctx, "/chat/completions", req, func(chunk []byte) error {
-			return sr.ProcessLine(chunk)
-		})
-		if err != nil {
+	buffer := o.buffer
+	if buffer < 1 {
+		buffer = DefaultStreamBuffer
+	}
+	sr := newStreamReader(buffer, o.backpressure)
+	sr.start(ctx, func(ctx context.Context) {
+		err := s.streamChunks(ctx, req, o, false, sr.deliver)
+		if err != nil && !sr.stopped() {
 			sr.SetError(err)
 		} else {
-			sr.Close()
+			sr.finish()
 		}
-	}()
+	})
 	return sr, nil
 }
+
+// CreateStreamFunc sends a streaming chat completion request and calls fn for
+// each chunk, including a final usage-only chunk, on the calling goroutine.
+// It returns when the stream ends, fails, or fn returns an error (which aborts
+// the request and is returned).
+//
+// Unlike CreateStream there is no goroutine or channel, and the chunk passed
+// to fn, its slices and the read buffers are reused: fn must copy anything it
+// keeps after returning. This is the path for high-throughput gateways.
+func (s *Service) CreateStreamFunc(ctx context.Context, req *ChatRequest, fn func(*StreamChunk) error, opts ...StreamOption) error {
+	if req == nil {
+		req = &ChatRequest{}
+	}
+	req.Stream = true
+	return s.streamChunks(ctx, req, s.streamOptions(opts), true, fn)
+}
+
+// streamOptions applies the service defaults and then opts.
+func (s *Service) streamOptions(opts []StreamOption) streamOptions {
+	var o streamOptions
+	for _, opt := range s.streamDefaults {
+		opt(&o)
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// errStreamDone stops reading once the [DONE] sentinel arrives.
+var errStreamDone = errors.New("stream done")
+
+// streamChunks passes the chunks of one or more streaming requests to emit,
+// failing over to the next fallback model when an error chunk arrives. With
+// reuse set, every chunk is decoded into the same StreamChunk.
+func (s *Service) streamChunks(ctx context.Context, req *ChatRequest, o streamOptions, reuse bool, emit func(*StreamChunk) error) error {
+	ctx, op := s.caller.StartOp(ctx, observe.Operation{Name: observe.OpChatStream, Endpoint: "/chat/completions", Model: req.Model, Request: req})
+	var res observe.Result
+	defer func() { op.End(res) }()
+	ctx, wd := newWatchdog(ctx, o.timeouts)
+	defer wd.stop()
+
+	var scratch StreamChunk
+	models := append([]string{req.Model}, o.fallbackModels...)
+	var partial strings.Builder
+	cur := req
+	for i := range models {
+		var chunkErr error
+		err := s.caller.DoStreamPost(withRequestInfo(ctx, cur), "/chat/completions", cur, wd.attempt, func(ev sse.Event) error {
+			wd.activity()
+			var chunk *StreamChunk
+			var done bool
+			var err error
+			if reuse {
+				chunk, done, err = decodeEventInto(ev, &scratch)
+			} else {
+				chunk, done, err = decodeEvent(ev)
+			}
+			switch {
+			case done:
+				return errStreamDone
+			case err != nil:
+				return err
+			case chunk == nil:
+				return nil
+			case chunk.Error != nil:
+				chunkErr = chunk.Error.toError()
+				return chunkErr
+			}
+			content := chunk.hasContent()
+			if content {
+				wd.firstToken()
+			}
+			if op != nil {
+				if content {
+					op.FirstToken()
+				}
+				addChunkResult(&res, chunk)
+			}
+			if len(o.fallbackModels) > 0 {
+				partial.WriteString(chunk.text())
+			}
+			wd.pause()
+			defer wd.activity()
+			return emit(chunk)
+		})
+		if errors.Is(err, errStreamDone) {
+			err = nil
+		}
+		err = wd.err(ctx, err)
+		if chunkErr == nil || i == len(models)-1 || ctx.Err() != nil {
+			res.Err = err
+			return err
+		}
+		cur = continueRequest(req, models[i+1], partial.String())
+	}
+	return nil
+}
+
+// continueRequest copies req for model, appending the partial assistant reply
+// so the model continues it.
+func continueRequest(req *ChatRequest, model, partial string) *ChatRequest {
+	r := *req
+	r.Model = model
+	if partial != "" {
+		r.Messages = append(append([]Message(nil), req.Messages...), Message{Role: "assistant", Content: partial})
+	}
+	return &r
+}
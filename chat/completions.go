@@ -1,6 +1,10 @@
 package chat
 
-import "context"
+import (
+	"context"
+
+	"github.com/MetaDiv-AI/openrouter/internal"
+)
 
 // CompletionsRequest is the legacy prompt-based completions request.
 type CompletionsRequest struct {
@@ -26,6 +30,11 @@ func (s *Service) CreateCompletions(ctx context.Context, req *CompletionsRequest
 	if req == nil {
 		req = &CompletionsRequest{}
 	}
+	tokens := internal.EstimateTokens(len(req.Prompt))
+	if req.MaxTokens != nil {
+		tokens += *req.MaxTokens
+	}
+	ctx = internal.WithRequestInfo(ctx, internal.RequestInfo{Model: req.Model, EstimatedTokens: tokens})
 
 	var resp CompletionsResponse
 	if err := s.caller.DoPost(ctx, "/chat/completions", req, &resp); err != nil {
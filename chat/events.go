@@ -0,0 +1,147 @@
+package chat
+
+import "iter"
+
+// Event is a typed stream event produced by StreamReader.Events. Use a type
+// switch over the concrete event types. Choice is the choice index the event
+// belongs to, or -1 for events that concern the whole stream.
+type Event interface {
+	ChoiceIndex() int
+}
+
+// TextDelta carries a piece of the response text.
+type TextDelta struct {
+	Choice int
+	Text   string
+}
+
+// ReasoningDelta carries a piece of the model's reasoning text.
+type ReasoningDelta struct {
+	Choice int
+	Text   string
+}
+
+// ToolCallStarted is emitted when a new tool call appears in the stream.
+type ToolCallStarted struct {
+	Choice int
+	Index  int
+	ID     string
+	Name   string
+}
+
+// ToolCallArgumentsDelta carries a fragment of a tool call's JSON arguments.
+type ToolCallArgumentsDelta struct {
+	Choice int
+	Index  int
+	Delta  string
+}
+
+// ToolCallCompleted carries a fully assembled tool call. It is emitted when
+// the choice finishes, or when the stream ends.
+type ToolCallCompleted struct {
+	Choice int
+	Call   ToolCall
+}
+
+// FinishReason is emitted when a choice finishes.
+type FinishReason struct {
+	Choice int
+	Reason string
+}
+
+// UsageReport carries the token usage reported by a chunk, normally the last
+// one. Some providers attach it to the finish chunk instead of sending a
+// usage-only chunk.
+type UsageReport struct {
+	Usage Usage
+}
+
+// ProviderError is emitted when a choice carries an upstream provider error.
+// Errors that end the whole stream are returned as the iterator error instead.
+type ProviderError struct {
+	Choice  int
+	Code    int
+	Message string
+}
+
+func (e TextDelta) ChoiceIndex() int              { return e.Choice }
+func (e ReasoningDelta) ChoiceIndex() int         { return e.Choice }
+func (e ToolCallStarted) ChoiceIndex() int        { return e.Choice }
+func (e ToolCallArgumentsDelta) ChoiceIndex() int { return e.Choice }
+func (e ToolCallCompleted) ChoiceIndex() int      { return e.Choice }
+func (e FinishReason) ChoiceIndex() int           { return e.Choice }
+func (e UsageReport) ChoiceIndex() int            { return -1 }
+func (e ProviderError) ChoiceIndex() int          { return e.Choice }
+
+// Events returns an iterator over typed events. Within a chunk, each choice
+// yields reasoning, text, tool call and provider error events, then its
+// completed tool calls and finish reason; a UsageReport follows the choice
+// events of a chunk that carries usage. Breaking out of the loop closes the
+// stream.
+func (sr *StreamReader) Events() iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		acc := newToolCallAccumulator()
+		var events []Event
+		for chunk, err := range sr.All() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			events = appendChunkEvents(events[:0], acc, chunk)
+			for _, e := range events {
+				if !yield(e, nil) {
+					return
+				}
+			}
+		}
+		for _, choice := range acc.pending() {
+			for _, tc := range acc.complete(choice) {
+				if !yield(ToolCallCompleted{Choice: choice, Call: tc}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// appendChunkEvents appends the events of one chunk to events.
+func appendChunkEvents(events []Event, acc *toolCallAccumulator, chunk *StreamChunk) []Event {
+	for _, c := range chunk.Choices {
+		if d := c.Delta; d != nil {
+			if d.Reasoning != "" {
+				events = append(events, ReasoningDelta{Choice: c.Index, Text: d.Reasoning})
+			}
+			if s, _ := d.Content.(string); s != "" {
+				events = append(events, TextDelta{Choice: c.Index, Text: s})
+			}
+			if len(d.ToolCalls) > 0 {
+				for _, tc := range acc.add(c.Index, d.ToolCalls) {
+					events = append(events, ToolCallStarted{Choice: c.Index, Index: *tc.Index, ID: tc.ID, Name: tc.Function.Name})
+				}
+				for i, tc := range d.ToolCalls {
+					if tc.Function.Arguments == "" {
+						continue
+					}
+					idx := i
+					if tc.Index != nil {
+						idx = *tc.Index
+					}
+					events = append(events, ToolCallArgumentsDelta{Choice: c.Index, Index: idx, Delta: tc.Function.Arguments})
+				}
+			}
+		}
+		if c.Error != nil {
+			events = append(events, ProviderError{Choice: c.Index, Code: c.Error.Code, Message: c.Error.Message})
+		}
+		if c.FinishReason != "" {
+			for _, tc := range acc.complete(c.Index) {
+				events = append(events, ToolCallCompleted{Choice: c.Index, Call: tc})
+			}
+			events = append(events, FinishReason{Choice: c.Index, Reason: c.FinishReason})
+		}
+	}
+	if chunk.Usage != nil {
+		events = append(events, UsageReport{Usage: *chunk.Usage})
+	}
+	return events
+}
@@ -0,0 +1,61 @@
+package chat_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+)
+
+func TestEventsUsage(t *testing.T) {
+	usage := &chat.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}
+	tests := []struct {
+		name  string
+		reply openroutertest.Reply
+		want  []string
+	}{
+		{
+			name:  "usage-only chunk",
+			reply: openroutertest.Text("hi there").WithUsage(*usage),
+			want:  []string{"text hi ", "text there", "finish stop", "usage 5"},
+		},
+		{
+			name: "usage on the finish chunk",
+			reply: openroutertest.Reply{Events: []openroutertest.Event{
+				{Chunk: &chat.StreamChunk{Choices: []chat.Choice{{Delta: &chat.Message{Content: "hi"}}}}},
+				{Chunk: &chat.StreamChunk{Choices: []chat.Choice{{Delta: &chat.Message{}, FinishReason: "stop"}}, Usage: usage}},
+			}},
+			want: []string{"text hi", "finish stop", "usage 5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openroutertest.NewServer(t)
+			srv.Chat(tt.reply)
+			req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+			sr, err := srv.Client().Chat.CreateStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for ev, err := range sr.Events() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				switch ev := ev.(type) {
+				case chat.TextDelta:
+					got = append(got, "text "+ev.Text)
+				case chat.FinishReason:
+					got = append(got, "finish "+ev.Reason)
+				case chat.UsageReport:
+					got = append(got, fmt.Sprint("usage ", ev.Usage.TotalTokens))
+				}
+			}
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
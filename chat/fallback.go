@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// ModelSpec is one step of a client-side fallback chain.
+type ModelSpec struct {
+	Model string
+	// DropTools removes Tools, ToolChoice and ParallelToolCalls for models
+	// without tool support.
+	DropTools bool
+	// Adjust, if set, modifies the copy of the request sent to this model
+	// (e.g. lower MaxTokens). The copy is shallow: replace slices and maps
+	// rather than mutating them.
+	Adjust func(*ChatRequest)
+}
+
+// FallbackClassifier reports whether an API error should move the chain on to the next model.
+type FallbackClassifier func(err *errors.OpenRouterError) bool
+
+// DefaultFallbackClassifier switches models on moderation (403), context length
+// and unknown model errors, and on provider-side failures (408, 429, 5xx).
+func DefaultFallbackClassifier(err *errors.OpenRouterError) bool {
+	switch {
+	case err.Code == 403, err.Code == 404, err.Retryable():
+		return true
+	case err.Code == 400:
+		return isContextLengthError(err.Message)
+	default:
+		return false
+	}
+}
+
+func isContextLengthError(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "context length") ||
+		strings.Contains(msg, "context_length") ||
+		strings.Contains(msg, "context window")
+}
+
+// FallbackAttempt records a model tried by CreateWithFallback.
+type FallbackAttempt struct {
+	Model string
+	Err   error
+}
+
+// FallbackResult is the outcome of CreateWithFallback.
+type FallbackResult struct {
+	Response *ChatResponse
+	// Model is the spec model that answered; Response.Model is the model
+	// reported by OpenRouter.
+	Model    string
+	Attempts []FallbackAttempt
+}
+
+// CreateWithFallback tries each model in order until one succeeds. The chain
+// moves on when classify accepts the API error, or when the model's circuit
+// breaker is open; any other error is returned immediately. A nil classify
+// uses DefaultFallbackClassifier. The request's own Model is replaced by each
+// spec's Model and req itself is not modified.
+//
+// The returned result is non-nil even on error so the attempts can be inspected.
+func (s *Service) CreateWithFallback(ctx context.Context, req *ChatRequest, models []ModelSpec, classify FallbackClassifier) (*FallbackResult, error) {
+	if req == nil {
+		req = &ChatRequest{}
+	}
+	if classify == nil {
+		classify = DefaultFallbackClassifier
+	}
+	result := &FallbackResult{}
+	if len(models) == 0 {
+		return result, &errors.OpenRouterError{Code: 400, Message: "fallback chain has no models"}
+	}
+
+	var lastErr error
+	for _, spec := range models {
+		resp, err := s.Create(ctx, spec.apply(req))
+		result.Attempts = append(result.Attempts, FallbackAttempt{Model: spec.Model, Err: err})
+		if err == nil {
+			result.Response = resp
+			result.Model = spec.Model
+			return result, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !shouldFallback(err, classify) {
+			return result, err
+		}
+	}
+	return result, lastErr
+}
+
+// apply returns the request to send for this spec.
+func (spec ModelSpec) apply(req *ChatRequest) *ChatRequest {
+	r := *req
+	r.Model = spec.Model
+	if spec.DropTools {
+		r.Tools = nil
+		r.ToolChoice = nil
+		r.ParallelToolCalls = nil
+	}
+	if spec.Adjust != nil {
+		spec.Adjust(&r)
+	}
+	return &r
+}
+
+func shouldFallback(err error, classify FallbackClassifier) bool {
+	if stderrors.Is(err, errors.ErrCircuitOpen) {
+		return true
+	}
+	var oerr *errors.OpenRouterError
+	return stderrors.As(err, &oerr) && classify(oerr)
+}
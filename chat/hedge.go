@@ -0,0 +1,149 @@
+package chat
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// HedgeOptions configures CreateHedged.
+type HedgeOptions struct {
+	// Delay is how long to wait for the primary request before sending the
+	// hedge. Zero sends both at once. The hedge is also sent early if the
+	// primary fails before Delay with an error the hedge may not repeat.
+	Delay time.Duration
+	// Model is the model used for the hedge. Empty means the request's model.
+	Model string
+	// Classify decides which primary errors send a hedge to a different model
+	// early. Nil uses DefaultFallbackClassifier. A hedge to the same model is
+	// only sent early on retryable errors.
+	Classify FallbackClassifier
+}
+
+// HedgeAttempt records one request sent by CreateHedged.
+type HedgeAttempt struct {
+	Model    string
+	Response *ChatResponse
+	Err      error
+	Latency  time.Duration
+	// Cancelled is true when the attempt was aborted because the other one won.
+	Cancelled bool
+}
+
+// HedgeResult is the outcome of CreateHedged.
+type HedgeResult struct {
+	Response *ChatResponse
+	// Winner is the index in Attempts of the response returned, or -1.
+	Winner   int
+	Attempts []HedgeAttempt
+	// Usage sums the usage of every attempt that completed. A cancelled
+	// attempt reports no usage but may still be billed for tokens generated
+	// before it was aborted.
+	Usage Usage
+}
+
+// CreateHedged sends req and, if it has not completed within opts.Delay, a
+// duplicate to the same or an alternate model. The first successful response
+// wins and the other request is cancelled through its context. CreateHedged
+// waits for the loser to return, so no request outlives the call.
+//
+// The returned result is non-nil even on error so the attempts can be inspected.
+func (s *Service) CreateHedged(ctx context.Context, req *ChatRequest, opts HedgeOptions) (*HedgeResult, error) {
+	if req == nil {
+		req = &ChatRequest{}
+	}
+	hedgeModel := opts.Model
+	if hedgeModel == "" {
+		hedgeModel = req.Model
+	}
+	classify := opts.Classify
+	if classify == nil {
+		classify = DefaultFallbackClassifier
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		idx  int
+		resp *ChatResponse
+		err  error
+	}
+	outcomes := make(chan outcome, 2)
+	result := &HedgeResult{Winner: -1}
+	var starts []time.Time
+	launch := func(model string) {
+		idx := len(result.Attempts)
+		result.Attempts = append(result.Attempts, HedgeAttempt{Model: model})
+		starts = append(starts, time.Now())
+		r := *req
+		r.Model = model
+		go func() {
+			resp, err := s.Create(ctx, &r)
+			outcomes <- outcome{idx: idx, resp: resp, err: err}
+		}()
+	}
+
+	launch(req.Model)
+	pending := 1
+	timer := time.NewTimer(opts.Delay)
+	defer timer.Stop()
+	hedged := false
+	hedge := func() {
+		if !hedged && ctx.Err() == nil {
+			hedged = true
+			pending++
+			launch(hedgeModel)
+		}
+	}
+
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			hedge()
+		case o := <-outcomes:
+			pending--
+			a := &result.Attempts[o.idx]
+			a.Response, a.Err, a.Latency = o.resp, o.err, time.Since(starts[o.idx])
+			if o.resp != nil && o.resp.Usage != nil {
+				result.Usage.add(o.resp.Usage)
+			}
+			switch {
+			case o.err == nil && result.Winner < 0:
+				result.Winner = o.idx
+				result.Response = o.resp
+				cancel()
+			case o.err != nil && result.Winner >= 0:
+				a.Cancelled = true
+			case o.err != nil && hedgeAfter(o.err, hedgeModel == req.Model, classify):
+				hedge()
+			}
+		}
+	}
+
+	if result.Winner >= 0 {
+		return result, nil
+	}
+	return result, result.Attempts[0].Err
+}
+
+// hedgeAfter reports whether the hedge may succeed where a request failed
+// with err: a retryable error for the same model, or one classify accepts for
+// another.
+func hedgeAfter(err error, sameModel bool, classify FallbackClassifier) bool {
+	if sameModel {
+		var oerr *errors.OpenRouterError
+		return stderrors.As(err, &oerr) && oerr.Retryable()
+	}
+	return shouldFallback(err, classify)
+}
+
+// add accumulates o into u.
+func (u *Usage) add(o *Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.TotalTokens += o.TotalTokens
+	u.Cost += o.Cost
+}
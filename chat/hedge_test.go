@@ -0,0 +1,106 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter"
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+	"github.com/MetaDiv-AI/openrouter/retry"
+)
+
+func TestCreateHedged(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     chat.HedgeOptions
+		replies  []openroutertest.Reply
+		wantErr  bool
+		winner   int
+		attempts int
+	}{
+		{
+			name:     "primary wins before the delay",
+			opts:     chat.HedgeOptions{Delay: time.Second},
+			replies:  []openroutertest.Reply{openroutertest.Text("primary")},
+			winner:   0,
+			attempts: 1,
+		},
+		{
+			name:     "slow primary is hedged",
+			opts:     chat.HedgeOptions{Delay: 20 * time.Millisecond, Model: "backup"},
+			replies:  []openroutertest.Reply{openroutertest.Text("primary").WithDelay(time.Second), openroutertest.Text("hedge")},
+			winner:   1,
+			attempts: 2,
+		},
+		{
+			name:     "retryable failure hedges early",
+			opts:     chat.HedgeOptions{Delay: time.Second},
+			replies:  []openroutertest.Reply{openroutertest.Error(503, "overloaded"), openroutertest.Text("hedge")},
+			winner:   1,
+			attempts: 2,
+		},
+		{
+			name:     "bad request is not hedged",
+			opts:     chat.HedgeOptions{Delay: time.Second, Model: "backup"},
+			replies:  []openroutertest.Reply{openroutertest.Error(400, "invalid request")},
+			wantErr:  true,
+			winner:   -1,
+			attempts: 1,
+		},
+		{
+			name:     "insufficient credits are not hedged",
+			opts:     chat.HedgeOptions{Delay: time.Second},
+			replies:  []openroutertest.Reply{openroutertest.Error(402, "insufficient credits")},
+			wantErr:  true,
+			winner:   -1,
+			attempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openroutertest.NewServer(t)
+			srv.Chat(tt.replies...)
+			noRetry := retry.DefaultPolicy()
+			noRetry.MaxAttempts = 1
+			client := srv.Client(openrouter.WithRetryPolicy(noRetry))
+			req := &chat.ChatRequest{Model: "primary", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+
+			res, err := client.Chat.CreateHedged(context.Background(), req, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if res.Winner != tt.winner {
+				t.Errorf("Winner = %d, want %d", res.Winner, tt.winner)
+			}
+			if len(res.Attempts) != tt.attempts {
+				t.Errorf("len(Attempts) = %d, want %d", len(res.Attempts), tt.attempts)
+			}
+			srv.AssertRequests(t, "/chat/completions", tt.attempts)
+		})
+	}
+}
+
+func TestCreateHedgedLatency(t *testing.T) {
+	srv := openroutertest.NewServer(t)
+	srv.Chat(openroutertest.Text("primary").WithDelay(time.Second), openroutertest.Text("hedge"))
+	client := srv.Client()
+	req := &chat.ChatRequest{Model: "primary", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+
+	delay := 100 * time.Millisecond
+	res, err := client.Chat.CreateHedged(context.Background(), req, chat.HedgeOptions{Delay: delay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, hedge := res.Attempts[0], res.Attempts[1]
+	if hedge.Latency >= delay {
+		t.Errorf("hedge latency %v includes the hedge delay %v", hedge.Latency, delay)
+	}
+	if primary.Latency < delay {
+		t.Errorf("primary latency %v, want at least %v", primary.Latency, delay)
+	}
+	if !primary.Cancelled {
+		t.Error("primary not marked cancelled")
+	}
+}
@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the stream's chunks. A final usage-only chunk
+// is yielded if the stream reported usage. Iteration stops after the first
+// error, which is yielded with a nil chunk. Breaking out of the loop closes
+// the stream, aborting the request.
+//
+//	for chunk, err := range stream.All() {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}
+func (sr *StreamReader) All() iter.Seq2[*StreamChunk, error] {
+	return func(yield func(*StreamChunk, error) bool) {
+		defer sr.Close()
+		for {
+			chunk, err := sr.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Text returns an iterator over the text deltas of the first choice.
+func (sr *StreamReader) Text() iter.Seq2[string, error] {
+	return sr.deltas(func(m *Message) string {
+		s, _ := m.Content.(string)
+		return s
+	})
+}
+
+// Reasoning returns an iterator over the reasoning deltas of the first choice.
+func (sr *StreamReader) Reasoning() iter.Seq2[string, error] {
+	return sr.deltas(func(m *Message) string { return m.Reasoning })
+}
+
+// deltas yields the non-empty strings pick extracts from first-choice deltas.
+func (sr *StreamReader) deltas(pick func(*Message) string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for chunk, err := range sr.All() {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta == nil {
+				continue
+			}
+			if s := pick(chunk.Choices[0].Delta); s != "" && !yield(s, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ToolCalls returns an iterator over complete tool calls. Streamed deltas are
+// accumulated and each call is yielded once its choice finishes (or the
+// stream ends), with the arguments fully assembled.
+func (sr *StreamReader) ToolCalls() iter.Seq2[ToolCall, error] {
+	return func(yield func(ToolCall, error) bool) {
+		for e, err := range sr.Events() {
+			if err != nil {
+				yield(ToolCall{}, err)
+				return
+			}
+			if tc, ok := e.(ToolCallCompleted); ok && !yield(tc.Call, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package chat
+
+import "github.com/MetaDiv-AI/openrouter/observe"
+
+// result summarizes the response for observe hooks.
+func (r *ChatResponse) result() observe.Result {
+	res := observe.Result{Model: r.Model, Provider: r.Provider, ID: r.ID, Response: r}
+	for _, c := range r.Choices {
+		res.FinishReasons = append(res.FinishReasons, c.FinishReason)
+	}
+	if r.Usage != nil {
+		res.InputTokens = r.Usage.PromptTokens
+		res.OutputTokens = r.Usage.CompletionTokens
+		res.Cost = r.Usage.Cost
+	}
+	return res
+}
+
+// addChunkResult folds a stream chunk into res.
+func addChunkResult(res *observe.Result, chunk *StreamChunk) {
+	if chunk.Model != "" {
+		res.Model = chunk.Model
+	}
+	if chunk.Provider != "" {
+		res.Provider = chunk.Provider
+	}
+	if chunk.ID != "" {
+		res.ID = chunk.ID
+	}
+	for _, c := range chunk.Choices {
+		if c.FinishReason != "" {
+			res.FinishReasons = append(res.FinishReasons, c.FinishReason)
+		}
+	}
+	if chunk.Usage != nil {
+		res.InputTokens = chunk.Usage.PromptTokens
+		res.OutputTokens = chunk.Usage.CompletionTokens
+		res.Cost = chunk.Usage.Cost
+	}
+}
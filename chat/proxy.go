@@ -0,0 +1,235 @@
+package chat
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// ProxyFormat is the wire format written by Proxy.
+type ProxyFormat int
+
+const (
+	// ProxySSE writes OpenAI-compatible server-sent events ending with "data: [DONE]".
+	ProxySSE ProxyFormat = iota
+	// ProxyNDJSON writes one JSON chunk per line.
+	ProxyNDJSON
+)
+
+// ProxyOptions configures Proxy.
+type ProxyOptions struct {
+	Format ProxyFormat
+	// Transform, if set, rewrites each chunk before it is written. Returning
+	// nil skips the chunk.
+	Transform func(*StreamChunk) *StreamChunk
+	// KeepAlive is the interval of ": keep-alive" comments sent while no
+	// chunk is written (SSE only): the interval restarts after every write.
+	// Zero disables them.
+	KeepAlive time.Duration
+}
+
+// proxyError is the error payload written when the stream fails, in the same
+// shape OpenRouter uses for mid-stream errors.
+type proxyError struct {
+	Error StreamError `json:"error"`
+}
+
+// Proxy writes the stream to w, flushing after every chunk, until the stream
+// ends or the client behind r disconnects. The response headers are sent with
+// the first chunk or keep-alive, so a stream that fails before then is answered
+// with the error's HTTP status and a JSON error body. A later stream error is
+// written as an error payload. Either way it is returned; only API errors and
+// stream timeouts are described to the client. On disconnect the
+// stream is closed, aborting the upstream request, and r's context error is
+// returned.
+func Proxy(w http.ResponseWriter, r *http.Request, sr *StreamReader, opts ProxyOptions) error {
+	defer sr.Close()
+	pw := &proxyWriter{w: w, rc: http.NewResponseController(w), format: opts.Format}
+
+	type result struct {
+		chunk *StreamChunk
+		err   error
+	}
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		for {
+			chunk, err := sr.Next()
+			select {
+			case results <- result{chunk: chunk, err: err}:
+			case <-r.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var keepAlive <-chan time.Time
+	if opts.KeepAlive > 0 && opts.Format == ProxySSE {
+		ticker := time.NewTicker(opts.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+		pw.wrote = func() { ticker.Reset(opts.KeepAlive) }
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return r.Context().Err()
+		case <-keepAlive:
+			if err := pw.write([]byte(": keep-alive\n\n")); err != nil {
+				return err
+			}
+		case res := <-results:
+			if res.chunk != nil {
+				if err := pw.chunk(res.chunk, opts.Transform); err != nil {
+					return err
+				}
+			}
+			if stderrors.Is(res.err, io.EOF) {
+				if opts.Format == ProxySSE {
+					return pw.write([]byte("data: [DONE]\n\n"))
+				}
+				return pw.flush()
+			}
+			if res.err != nil {
+				_ = pw.fail(res.err)
+				return res.err
+			}
+		}
+	}
+}
+
+// proxyWriter writes the frames of Proxy, sending the headers before the
+// first one.
+type proxyWriter struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	format ProxyFormat
+	sent   bool
+	// held are the chunks without content that arrived before the headers
+	// were sent. They are written ahead of the first frame, so an error that
+	// follows them still becomes the response status.
+	held []*StreamChunk
+	// wrote, if set, is called after every write.
+	wrote func()
+}
+
+// header sends the streaming response headers, once.
+func (p *proxyWriter) header() {
+	if p.sent {
+		return
+	}
+	p.sent = true
+	h := p.w.Header()
+	if p.format == ProxyNDJSON {
+		h.Set("Content-Type", "application/x-ndjson")
+	} else {
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Connection", "keep-alive")
+	}
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	p.w.WriteHeader(http.StatusOK)
+}
+
+// flush sends the headers and any held chunks.
+func (p *proxyWriter) flush() error {
+	if p.sent {
+		return nil
+	}
+	return p.write(nil)
+}
+
+func (p *proxyWriter) chunk(chunk *StreamChunk, transform func(*StreamChunk) *StreamChunk) error {
+	if transform != nil {
+		if chunk = transform(chunk); chunk == nil {
+			return nil
+		}
+	}
+	if !p.sent && !chunk.hasContent() {
+		p.held = append(p.held, chunk)
+		return nil
+	}
+	return p.payload(chunk)
+}
+
+// fail writes err: as the response status and body if nothing has been sent
+// yet, otherwise as an error payload in the stream.
+func (p *proxyWriter) fail(err error) error {
+	body := proxyError{Error: toStreamError(err)}
+	if p.sent {
+		return p.payload(body)
+	}
+	p.sent = true
+	data, merr := json.Marshal(body)
+	if merr != nil {
+		return merr
+	}
+	status := body.Error.Code
+	if status < 400 || status > 599 {
+		status = http.StatusBadGateway
+	}
+	p.w.Header().Set("Content-Type", "application/json")
+	p.w.WriteHeader(status)
+	_, werr := p.w.Write(append(data, '\n'))
+	return werr
+}
+
+func (p *proxyWriter) payload(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var frame []byte
+	if p.format == ProxyNDJSON {
+		frame = append(data, '\n')
+	} else {
+		frame = append(append([]byte("data: "), data...), '\n', '\n')
+	}
+	return p.write(frame)
+}
+
+func (p *proxyWriter) write(b []byte) error {
+	if !p.sent {
+		p.header()
+		held := p.held
+		p.held = nil
+		for _, c := range held {
+			if err := p.payload(c); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := p.w.Write(b); err != nil {
+		return err
+	}
+	if err := p.rc.Flush(); err != nil && !stderrors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if p.wrote != nil {
+		p.wrote()
+	}
+	return nil
+}
+
+// toStreamError converts err into the code/message pair clients expect. Only
+// API errors and stream timeouts are passed on; the text of other errors may
+// name internal hosts or addresses, so they get a generic message.
+func toStreamError(err error) StreamError {
+	var oerr *errors.OpenRouterError
+	if stderrors.As(err, &oerr) {
+		return StreamError{Code: oerr.Code, Message: oerr.Message}
+	}
+	var terr *errors.StreamTimeoutError
+	if stderrors.As(err, &terr) {
+		return StreamError{Code: http.StatusGatewayTimeout, Message: terr.Error()}
+	}
+	return StreamError{Code: http.StatusBadGateway, Message: "upstream stream failed"}
+}
@@ -0,0 +1,53 @@
+package chat_test
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+func TestProxyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantMsg    string
+	}{
+		{"api error", &errors.OpenRouterError{Code: 429, Message: "rate limited"}, 429, "rate limited"},
+		{"wrapped api error", fmt.Errorf("chat: %w", &errors.OpenRouterError{Code: 503, Message: "overloaded"}), 503, "overloaded"},
+		{"stream timeout", &errors.StreamTimeoutError{Kind: errors.IdleTimeout, Timeout: time.Second}, 504, "openrouter: stream idle timeout after 1s"},
+		{"internal error", stderrors.New("do request: dial tcp 10.1.2.3:443: connection refused"), 502, "upstream stream failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := chat.NewStreamReader()
+			sr.SetError(tt.err)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if err := chat.Proxy(rec, req, sr, chat.ProxyOptions{}); err == nil {
+				t.Fatal("Proxy returned nil, want the stream error")
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct{ Error chat.StreamError }
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q: %v", rec.Body, err)
+			}
+			if body.Error.Message != tt.wantMsg {
+				t.Errorf("message = %q, want %q", body.Error.Message, tt.wantMsg)
+			}
+			if strings.Contains(rec.Body.String(), "10.1.2.3") {
+				t.Errorf("body leaks the error: %s", rec.Body)
+			}
+		})
+	}
+}
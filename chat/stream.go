@@ -1,13 +1,16 @@
 package chat
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"sync"
+	"sync/atomic"
 
 	oerrors "github.com/MetaDiv-AI/openrouter/errors"
 	"github.com/MetaDiv-AI/openrouter/internal"
+	"github.com/MetaDiv-AI/openrouter/sse"
 )
 
 // StreamError represents an error in a streaming chunk (e.g. provider disconnect).
@@ -16,95 +19,290 @@ type StreamError struct {
 	Message string `json:"message"`
 }
 
+func (e *StreamError) toError() error {
+	return &oerrors.OpenRouterError{Code: e.Code, Message: e.Message}
+}
+
 // StreamChunk represents a single streaming chunk.
 type StreamChunk struct {
-	ID      string       `json:"id"`
-	Object  string       `json:"object"`
-	Created int64        `json:"created"`
-	Model   string       `json:"model"`
-	Choices []Choice     `json:"choices"`
-	Usage   *Usage       `json:"usage,omitempty"`
-	Error   *StreamError `json:"error,omitempty"`
+	ID       string       `json:"id"`
+	Object   string       `json:"object"`
+	Created  int64        `json:"created"`
+	Model    string       `json:"model"`
+	Provider string       `json:"provider,omitempty"`
+	Choices  []Choice     `json:"choices"`
+	Usage    *Usage       `json:"usage,omitempty"`
+	Error    *StreamError `json:"error,omitempty"`
 }
 
 // StreamReader reads SSE chat completion stream.
+//
+// The producer side (ProcessLine, SetError, or the request started by
+// CreateStream) ends the stream; the consumer side stops it early with Close.
+// Chunks, the final usage-only chunk and the terminal error all pass through
+// the reader's buffer in the order they were produced.
 type StreamReader struct {
-	ch     chan StreamChunk
-	done   chan struct{}
-	usage  *Usage
-	err    error
-	closed bool
-	mu     sync.Mutex
+	ch           chan streamItem
+	done         chan struct{} // closed by Close: the consumer stopped reading
+	backpressure Backpressure
+	err          error // terminal error received by Next
+	mu           sync.Mutex
+
+	sendMu    sync.RWMutex // held for reading while sending, for writing when closing ch
+	finished  bool         // ch is closed; guarded by sendMu
+	closeOnce sync.Once
+
+	sent      atomic.Int64
+	dropped   atomic.Int64
+	highWater atomic.Int64
+
+	cancel context.CancelFunc // aborts the producer's request
+	exited chan struct{}      // closed when the producer goroutine returns
 }
 
-// NewStreamReader creates a new StreamReader with a 256-chunk buffer.
-// Consumers should call Next() promptly to avoid blocking the producer.
+// streamItem is a chunk or the terminal error of a stream.
+type streamItem struct {
+	chunk StreamChunk
+	err   error
+}
+
+// BufferStats describes the occupancy of a StreamReader's buffer.
+type BufferStats struct {
+	// Len is the number of items waiting to be read.
+	Len int
+	// Cap is the buffer size.
+	Cap int
+	// HighWater is the largest Len observed after a send.
+	HighWater int
+	// Sent counts items handed to the buffer.
+	Sent int64
+	// Dropped counts items discarded by BackpressureDropOldest.
+	Dropped int64
+}
+
+// DefaultStreamBuffer is the number of chunks a StreamReader buffers by default.
+const DefaultStreamBuffer = 256
+
+// NewStreamReader creates a new StreamReader with a DefaultStreamBuffer-chunk
+// buffer. Consumers should call Next() promptly to avoid blocking the producer.
 func NewStreamReader() *StreamReader {
+	return newStreamReader(DefaultStreamBuffer, BackpressureBlock)
+}
+
+func newStreamReader(buffer int, backpressure Backpressure) *StreamReader {
 	return &StreamReader{
-		ch:   make(chan StreamChunk, 256),
-		done: make(chan struct{}),
+		ch:           make(chan streamItem, buffer),
+		done:         make(chan struct{}),
+		backpressure: backpressure,
+	}
+}
+
+// Stats returns a snapshot of the buffer's occupancy.
+func (sr *StreamReader) Stats() BufferStats {
+	return BufferStats{
+		Len:       len(sr.ch),
+		Cap:       cap(sr.ch),
+		HighWater: int(sr.highWater.Load()),
+		Sent:      sr.sent.Load(),
+		Dropped:   sr.dropped.Load(),
 	}
 }
 
 // ProcessLine processes a single SSE line (from http_caller ChunkHandler).
 func (sr *StreamReader) ProcessLine(line []byte) error {
+	chunk, done, err := decodeLine(line)
+	switch {
+	case done:
+		sr.finish()
+	case err != nil:
+		sr.SetError(err)
+	case chunk != nil && chunk.Error != nil:
+		sr.SetError(chunk.Error.toError())
+	case chunk != nil:
+		if err := sr.deliver(chunk); err != nil {
+			sr.SetError(err)
+		}
+	}
+	return nil
+}
+
+// decodeLine parses an SSE line. It returns a nil chunk for comments and blank
+// lines, and done for the [DONE] sentinel.
+func decodeLine(line []byte) (chunk *StreamChunk, done bool, err error) {
 	payload, done := internal.ParseSSELine(line)
-	if done {
-		sr.Close()
-		return nil
+	return decodePayload(payload, done)
+}
+
+// decodeEvent parses a server-sent event like decodeLine. Comments and events
+// other than "message" yield a nil chunk.
+func decodeEvent(ev sse.Event) (chunk *StreamChunk, done bool, err error) {
+	if ev.Type != "message" {
+		return nil, false, nil
 	}
-	if len(payload) == 0 {
-		return nil
+	payload, done := internal.ParseData(ev.Data)
+	return decodePayload(payload, done)
+}
+
+// decodeEventInto is decodeEvent decoding into chunk, reusing its Choices
+// backing array and their Delta messages. A choice without a delta is left
+// with an empty one rather than nil.
+func decodeEventInto(ev sse.Event, chunk *StreamChunk) (*StreamChunk, bool, error) {
+	if ev.Type != "message" {
+		return nil, false, nil
+	}
+	payload, done := internal.ParseData(ev.Data)
+	if done || len(payload) == 0 {
+		return nil, done, nil
 	}
+	choices := chunk.Choices[:cap(chunk.Choices)]
+	for i := range choices {
+		delta := choices[i].Delta
+		if delta != nil {
+			*delta = Message{}
+		}
+		choices[i] = Choice{Delta: delta}
+	}
+	*chunk = StreamChunk{Choices: choices[:0]}
+	if err := unmarshalChunk(payload, chunk); err != nil {
+		return nil, false, err
+	}
+	return chunk, false, nil
+}
 
-	var chunk StreamChunk
-	if err := json.Unmarshal(payload, &chunk); err != nil {
-		sr.SetError(&oerrors.OpenRouterError{Code: 500, Message: "malformed stream chunk: " + err.Error()})
-		return nil
+func decodePayload(payload []byte, done bool) (*StreamChunk, bool, error) {
+	if done || len(payload) == 0 {
+		return nil, done, nil
+	}
+	chunk := &StreamChunk{}
+	if err := unmarshalChunk(payload, chunk); err != nil {
+		return nil, false, err
 	}
-	if chunk.Error != nil {
-		sr.SetError(&oerrors.OpenRouterError{
-			Code:    chunk.Error.Code,
-			Message: chunk.Error.Message,
-		})
+	return chunk, false, nil
+}
+
+func unmarshalChunk(payload []byte, chunk *StreamChunk) error {
+	if err := json.Unmarshal(payload, chunk); err != nil {
+		return &oerrors.OpenRouterError{Code: 500, Message: "malformed stream chunk: " + err.Error()}
+	}
+	return nil
+}
+
+// deliver hands chunks with choices or usage to the consumer under the
+// reader's backpressure policy. It returns errors.ErrSlowConsumer if the
+// policy is BackpressureFail and the buffer is full.
+func (sr *StreamReader) deliver(chunk *StreamChunk) error {
+	if len(chunk.Choices) == 0 && chunk.Usage == nil {
 		return nil
 	}
-	if chunk.Usage != nil {
-		sr.mu.Lock()
-		sr.usage = chunk.Usage
-		sr.mu.Unlock()
+	if !sr.push(streamItem{chunk: *chunk}, sr.backpressure) && sr.backpressure == BackpressureFail && !sr.stopped() {
+		return oerrors.ErrSlowConsumer
+	}
+	return nil
+}
+
+// push hands item to the consumer according to policy. It returns false if
+// the item was not delivered because the stream has ended, the consumer
+// stopped reading, or (with BackpressureFail) the buffer is full.
+func (sr *StreamReader) push(item streamItem, policy Backpressure) bool {
+	sr.sendMu.RLock()
+	defer sr.sendMu.RUnlock()
+	if sr.finished {
+		return false
 	}
-	if len(chunk.Choices) > 0 {
+	switch policy {
+	case BackpressureDropOldest:
+		for {
+			select {
+			case sr.ch <- item:
+				sr.sentOne()
+				return true
+			case <-sr.done:
+				return false
+			default:
+			}
+			select {
+			case <-sr.ch:
+				sr.dropped.Add(1)
+			default:
+			}
+		}
+	case BackpressureFail:
 		select {
-		case sr.ch <- chunk:
+		case sr.ch <- item:
+			sr.sentOne()
+			return true
+		default:
+			return false
+		}
+	default:
+		select {
+		case sr.ch <- item:
+			sr.sentOne()
+			return true
 		case <-sr.done:
-			return nil
+			return false
 		}
 	}
-	return nil
 }
 
-// Next returns the next chunk or io.EOF when done.
+// sentOne updates the send statistics.
+func (sr *StreamReader) sentOne() {
+	sr.sent.Add(1)
+	n := int64(len(sr.ch))
+	for {
+		hw := sr.highWater.Load()
+		if n <= hw || sr.highWater.CompareAndSwap(hw, n) {
+			return
+		}
+	}
+}
+
+// text returns the text delta of the first choice, if any.
+func (c *StreamChunk) text() string {
+	if len(c.Choices) == 0 || c.Choices[0].Delta == nil {
+		return ""
+	}
+	s, _ := c.Choices[0].Delta.Content.(string)
+	return s
+}
+
+// hasContent reports whether any choice carries text, reasoning or tool call
+// deltas: the first such chunk is the stream's first token.
+func (c *StreamChunk) hasContent() bool {
+	for _, ch := range c.Choices {
+		d := ch.Delta
+		if d == nil {
+			continue
+		}
+		if s, _ := d.Content.(string); s != "" || d.Reasoning != "" || len(d.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the next chunk, or io.EOF when done. Chunks with no choices
+// carry the usage reported at the end of the stream. Once the stream's error
+// has been returned, Next keeps returning it.
 func (sr *StreamReader) Next() (*StreamChunk, error) {
 	sr.mu.Lock()
-	if sr.err != nil {
-		err := sr.err
-		sr.mu.Unlock()
+	err := sr.err
+	sr.mu.Unlock()
+	if err != nil {
 		return nil, err
 	}
-	sr.mu.Unlock()
 
-	chunk, ok := <-sr.ch
+	item, ok := <-sr.ch
 	if !ok {
+		return nil, io.EOF
+	}
+	if item.err != nil {
 		sr.mu.Lock()
-		usage := sr.usage
+		sr.err = item.err
 		sr.mu.Unlock()
-		if usage != nil {
-			return &StreamChunk{Usage: usage}, io.EOF
-		}
-		return nil, io.EOF
+		return nil, item.err
 	}
-	return &chunk, nil
+	return &item.chunk, nil
 }
 
 // ReadAll consumes the stream and returns the full content and usage.
@@ -114,46 +312,80 @@ func (sr *StreamReader) ReadAll() (string, *Usage, error) {
 	for {
 		chunk, err := sr.Next()
 		if errors.Is(err, io.EOF) {
-			if chunk != nil && chunk.Usage != nil {
-				usage = chunk.Usage
-			}
-			sr.mu.Lock()
-			if sr.usage != nil {
-				usage = sr.usage
-			}
-			sr.mu.Unlock()
 			return content, usage, nil
 		}
 		if err != nil {
 			return content, usage, err
 		}
-		if chunk != nil && len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil && chunk.Choices[0].Delta.Content != nil {
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil && chunk.Choices[0].Delta.Content != nil {
 			switch c := chunk.Choices[0].Delta.Content.(type) {
 			case string:
 				content += c
 			}
 		}
-		if chunk != nil && chunk.Usage != nil {
+		if chunk.Usage != nil {
 			usage = chunk.Usage
 		}
 	}
 }
 
-// Close closes the stream.
+// start runs produce in a goroutine bound to the reader: Close cancels the
+// context passed to produce and waits for it to return.
+func (sr *StreamReader) start(ctx context.Context, produce func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	sr.cancel = cancel
+	sr.exited = make(chan struct{})
+	go func() {
+		defer close(sr.exited)
+		defer cancel()
+		produce(ctx)
+	}()
+}
+
+// Close stops the stream. For streams from CreateStream it aborts the HTTP
+// request, releasing the connection, and returns once the producer goroutine
+// has exited. Close is safe to call more than once and from any goroutine
+// other than the producer.
 func (sr *StreamReader) Close() {
-	sr.mu.Lock()
-	defer sr.mu.Unlock()
-	if !sr.closed {
-		sr.closed = true
-		close(sr.done)
+	sr.closeOnce.Do(func() { close(sr.done) })
+	if sr.cancel != nil {
+		sr.cancel()
+	}
+	sr.finish()
+	if sr.exited != nil {
+		<-sr.exited
+	}
+}
+
+// finish ends the stream from the producer side: Next drains the buffered
+// chunks and then returns io.EOF or the error set by SetError.
+func (sr *StreamReader) finish() {
+	sr.sendMu.Lock()
+	defer sr.sendMu.Unlock()
+	if !sr.finished {
+		sr.finished = true
 		close(sr.ch)
 	}
 }
 
-// SetError sets the stream error.
+// stopped reports whether the consumer has called Close.
+func (sr *StreamReader) stopped() bool {
+	select {
+	case <-sr.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetError ends the stream with err, which Next returns after the chunks
+// already buffered. Unless the reader blocks on a full buffer, the oldest
+// buffered chunk is dropped to make room for the error.
 func (sr *StreamReader) SetError(err error) {
-	sr.mu.Lock()
-	sr.err = err
-	sr.mu.Unlock()
-	sr.Close()
+	policy := BackpressureBlock
+	if sr.backpressure != BackpressureBlock {
+		policy = BackpressureDropOldest
+	}
+	sr.push(streamItem{err: err}, policy)
+	sr.finish()
 }
@@ -0,0 +1,86 @@
+package chat
+
+import "time"
+
+// StreamOption configures CreateStream.
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	fallbackModels []string
+	timeouts       StreamTimeouts
+	buffer         int
+	backpressure   Backpressure
+}
+
+// StreamTimeouts bounds a stream independently of the client's unary request
+// timeout. Zero values disable the corresponding limit. Expiry ends the stream
+// with a *errors.StreamTimeoutError of the matching kind.
+//
+// FirstToken and Idle only run while a connection attempt is in flight:
+// retry backoff, Retry-After and rate limiter waits before a retry do not
+// count against them. Total does.
+type StreamTimeouts struct {
+	// FirstToken limits the wait for the first content chunk.
+	FirstToken time.Duration
+	// Idle limits the gap between any two events from the server; the
+	// keep-alive comments OpenRouter sends while processing count as activity.
+	Idle time.Duration
+	// Total limits the duration of the whole stream, including fallbacks.
+	Total time.Duration
+}
+
+// WithStreamFallback fails over to the given models, in order, when an error
+// chunk arrives mid-stream. The partial content of the first choice is sent
+// back as an assistant message so the next model continues where the last one
+// stopped, and the consumer sees a single uninterrupted stream.
+func WithStreamFallback(models ...string) StreamOption {
+	return func(o *streamOptions) {
+		o.fallbackModels = append(o.fallbackModels, models...)
+	}
+}
+
+// WithStreamTimeouts sets all stream timeouts at once.
+func WithStreamTimeouts(t StreamTimeouts) StreamOption {
+	return func(o *streamOptions) {
+		o.timeouts = t
+	}
+}
+
+// WithFirstTokenTimeout limits the wait for the first content chunk.
+func WithFirstTokenTimeout(d time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.timeouts.FirstToken = d
+	}
+}
+
+// WithIdleTimeout limits the gap between events, keep-alive comments included.
+func WithIdleTimeout(d time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.timeouts.Idle = d
+	}
+}
+
+// WithTotalTimeout limits the duration of the whole stream.
+func WithTotalTimeout(d time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.timeouts.Total = d
+	}
+}
+
+// WithStreamBuffer sets the number of chunks the StreamReader buffers ahead of
+// the consumer. Values below 1 use DefaultStreamBuffer.
+func WithStreamBuffer(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.buffer = n
+	}
+}
+
+// WithBackpressure sets what happens when the consumer falls behind and the
+// buffer is full. With BackpressureFail the request is aborted and the stream
+// ends with errors.ErrSlowConsumer; with BackpressureDropOldest the dropped
+// chunks are counted in StreamReader.Stats.
+func WithBackpressure(b Backpressure) StreamOption {
+	return func(o *streamOptions) {
+		o.backpressure = b
+	}
+}
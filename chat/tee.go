@@ -0,0 +1,141 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// TeeConsumer configures one output of StreamReader.Tee.
+type TeeConsumer struct {
+	// Buffer is the number of chunks buffered for this consumer. Defaults to
+	// DefaultStreamBuffer.
+	Buffer int
+	// Backpressure decides what happens when the buffer is full. With the
+	// default BackpressureBlock a slow consumer slows down all others.
+	Backpressure Backpressure
+}
+
+// Tee fans the stream out to one reader per consumer. Every reader sees the
+// same chunks (subject to its backpressure policy), the same final usage and
+// the same terminal error. Each reader gets its own copy of a chunk's choices,
+// messages and tool calls, so a consumer may modify the chunks it reads. Tee takes over the source: do not read from sr
+// afterwards. The source is closed, aborting the request, once every output
+// has been closed.
+func (sr *StreamReader) Tee(consumers ...TeeConsumer) []*StreamReader {
+	ctx, cancel := context.WithCancel(context.Background())
+	var open atomic.Int32
+	open.Store(int32(len(consumers)))
+	outs := make([]*StreamReader, len(consumers))
+	for i, c := range consumers {
+		buffer := c.Buffer
+		if buffer <= 0 {
+			buffer = DefaultStreamBuffer
+		}
+		out := newStreamReader(buffer, c.Backpressure)
+		// Closing the last output closes the source right away rather than
+		// when the next chunk finds no one to deliver it to.
+		out.start(ctx, func(ctx context.Context) {
+			<-ctx.Done()
+			if out.stopped() && open.Add(-1) == 0 {
+				sr.Close()
+			}
+		})
+		outs[i] = out
+	}
+	go func() {
+		defer cancel()
+		sr.tee(outs)
+	}()
+	return outs
+}
+
+func (sr *StreamReader) tee(outs []*StreamReader) {
+	defer sr.Close()
+	failed := make([]bool, len(outs))
+	for {
+		chunk, err := sr.Next()
+		if errors.Is(err, io.EOF) {
+			for _, out := range outs {
+				out.finish()
+			}
+			return
+		}
+		if err != nil {
+			for _, out := range outs {
+				out.SetError(err)
+			}
+			return
+		}
+
+		active := 0
+		for i, out := range outs {
+			if failed[i] || out.stopped() {
+				continue
+			}
+			// The last output gets the original, once every copy is made.
+			c := chunk
+			if i < len(outs)-1 {
+				c = cloneChunk(chunk)
+			}
+			if err := out.deliver(c); err != nil {
+				out.SetError(err)
+				failed[i] = true
+				continue
+			}
+			active++
+		}
+		if active == 0 {
+			return
+		}
+	}
+}
+
+// cloneChunk copies a chunk deeply enough that modifying the copy's choices,
+// messages, tool calls or usage leaves the original untouched.
+func cloneChunk(chunk *StreamChunk) *StreamChunk {
+	c := *chunk
+	if chunk.Choices != nil {
+		c.Choices = make([]Choice, len(chunk.Choices))
+		for i, choice := range chunk.Choices {
+			choice.Message = cloneMessage(choice.Message)
+			choice.Delta = cloneMessage(choice.Delta)
+			if choice.Error != nil {
+				e := *choice.Error
+				choice.Error = &e
+			}
+			c.Choices[i] = choice
+		}
+	}
+	if chunk.Usage != nil {
+		u := *chunk.Usage
+		c.Usage = &u
+	}
+	if chunk.Error != nil {
+		e := *chunk.Error
+		c.Error = &e
+	}
+	return &c
+}
+
+func cloneMessage(m *Message) *Message {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	if parts, ok := m.Content.([]ContentPart); ok {
+		c.Content = append([]ContentPart(nil), parts...)
+	}
+	if m.ToolCalls != nil {
+		c.ToolCalls = make([]ToolCall, len(m.ToolCalls))
+		for i, tc := range m.ToolCalls {
+			if tc.Index != nil {
+				idx := *tc.Index
+				tc.Index = &idx
+			}
+			c.ToolCalls[i] = tc
+		}
+	}
+	return &c
+}
@@ -0,0 +1,41 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+)
+
+func TestTeeCopiesChunks(t *testing.T) {
+	srv := openroutertest.NewServer(t)
+	srv.Chat(openroutertest.Text("one two three"))
+	req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+	sr, err := srv.Client().Chat.CreateStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outs := sr.Tee(chat.TeeConsumer{}, chat.TeeConsumer{}, chat.TeeConsumer{})
+
+	// Each output overwrites what it reads; the others must not notice.
+	for i, out := range outs {
+		var text string
+		for chunk, err := range out.All() {
+			if err != nil {
+				t.Fatalf("output %d: %v", i, err)
+			}
+			for j := range chunk.Choices {
+				if d := chunk.Choices[j].Delta; d != nil {
+					s, _ := d.Content.(string)
+					text += s
+					d.Content = "overwritten"
+				}
+			}
+			chunk.Choices = append(chunk.Choices[:0], chat.Choice{})
+		}
+		if text != "one two three" {
+			t.Errorf("output %d read %q", i, text)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/MetaDiv-AI/openrouter/internal"
+)
+
+// estimateTokens gives a rough token count for rate limiting: prompt text
+// plus the requested completion budget.
+func estimateTokens(req *ChatRequest) int {
+	chars := len(req.Prompt)
+	for _, m := range req.Messages {
+		switch c := m.Content.(type) {
+		case string:
+			chars += len(c)
+		case []ContentPart:
+			for _, p := range c {
+				chars += len(p.Text)
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			chars += len(tc.Function.Arguments)
+		}
+	}
+	tokens := internal.EstimateTokens(chars)
+	if req.MaxTokens != nil {
+		tokens += *req.MaxTokens
+	}
+	return tokens
+}
+
+// withRequestInfo attaches the model and token estimate of req to ctx.
+func withRequestInfo(ctx context.Context, req *ChatRequest) context.Context {
+	return internal.WithRequestInfo(ctx, internal.RequestInfo{
+		Model:           req.Model,
+		EstimatedTokens: estimateTokens(req),
+	})
+}
@@ -0,0 +1,69 @@
+package chat
+
+import "sort"
+
+// toolCallAccumulator assembles streamed tool call deltas into complete calls,
+// per choice.
+type toolCallAccumulator struct {
+	choices map[int]map[int]*ToolCall
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{choices: make(map[int]map[int]*ToolCall)}
+}
+
+// add merges the deltas of one choice and reports which calls are new.
+// Deltas without an Index are keyed by their position in the delta.
+func (a *toolCallAccumulator) add(choice int, deltas []ToolCall) (started []*ToolCall) {
+	calls := a.choices[choice]
+	if calls == nil {
+		calls = make(map[int]*ToolCall)
+		a.choices[choice] = calls
+	}
+	for i, d := range deltas {
+		idx := i
+		if d.Index != nil {
+			idx = *d.Index
+		}
+		tc, ok := calls[idx]
+		if !ok {
+			n := idx
+			tc = &ToolCall{Index: &n}
+			calls[idx] = tc
+			started = append(started, tc)
+		}
+		if d.ID != "" {
+			tc.ID = d.ID
+		}
+		if d.Type != "" {
+			tc.Type = d.Type
+		}
+		if d.Function.Name != "" {
+			tc.Function.Name = d.Function.Name
+		}
+		tc.Function.Arguments += d.Function.Arguments
+	}
+	return started
+}
+
+// complete removes and returns the calls of a choice in index order.
+func (a *toolCallAccumulator) complete(choice int) []ToolCall {
+	calls := a.choices[choice]
+	delete(a.choices, choice)
+	out := make([]ToolCall, 0, len(calls))
+	for _, tc := range calls {
+		out = append(out, *tc)
+	}
+	sort.Slice(out, func(i, j int) bool { return *out[i].Index < *out[j].Index })
+	return out
+}
+
+// pending returns the choices that still have incomplete calls, in order.
+func (a *toolCallAccumulator) pending() []int {
+	out := make([]int, 0, len(a.choices))
+	for choice := range a.choices {
+		out = append(out, choice)
+	}
+	sort.Ints(out)
+	return out
+}
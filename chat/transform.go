@@ -0,0 +1,54 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Transform returns a reader yielding the chunks of sr as rewritten by fn, for
+// middleware that edits a stream. fn is called with each chunk, which it may
+// modify, and returns the chunks to deliver in its place (none to drop it). At
+// the end of the stream fn is called once more with a nil chunk and the
+// stream's error, so it can return chunks it held back. The error is nil if
+// the stream completed, and context.Canceled if the returned reader was closed
+// first.
+//
+// The returned reader keeps sr's buffer size and backpressure policy. Transform
+// takes over sr: do not read from it afterwards. Closing the returned reader
+// closes sr.
+func (sr *StreamReader) Transform(fn func(chunk *StreamChunk, err error) []*StreamChunk) *StreamReader {
+	out := newStreamReader(cap(sr.ch), sr.backpressure)
+	out.start(context.Background(), func(ctx context.Context) {
+		stop := context.AfterFunc(ctx, sr.Close)
+		defer stop()
+		defer sr.Close()
+		for {
+			chunk, err := sr.Next()
+			if err != nil {
+				switch {
+				case out.stopped():
+					err = context.Canceled
+				case errors.Is(err, io.EOF):
+					err = nil
+				}
+				for _, c := range fn(nil, err) {
+					_ = out.deliver(c)
+				}
+				if err != nil {
+					out.SetError(err)
+				} else {
+					out.finish()
+				}
+				return
+			}
+			for _, c := range fn(chunk, nil) {
+				if err := out.deliver(c); err != nil {
+					out.SetError(err)
+					return
+				}
+			}
+		}
+	})
+	return out
+}
@@ -113,13 +113,15 @@ type ChatRequest struct {
 }
 
 // ChatResponse is the response from chat completions.
+// Provider is the upstream provider that served the request (e.g. "Anthropic").
 type ChatResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   *Usage   `json:"usage,omitempty"`
+	ID       string   `json:"id"`
+	Object   string   `json:"object"`
+	Created  int64    `json:"created"`
+	Model    string   `json:"model"`
+	Provider string   `json:"provider,omitempty"`
+	Choices  []Choice `json:"choices"`
+	Usage    *Usage   `json:"usage,omitempty"`
 }
 
 // ChoiceError represents provider error details in a choice.
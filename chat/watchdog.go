@@ -0,0 +1,117 @@
+package chat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// watchdog enforces stream timeouts by cancelling the stream context with a
+// *errors.StreamTimeoutError as its cause. The first-token and idle timers
+// only run while a connection attempt is in flight, so retry backoff and
+// rate limiter waits do not count against them.
+type watchdog struct {
+	cancel    context.CancelCauseFunc
+	timeouts  StreamTimeouts
+	mu        sync.Mutex
+	idleTimer *time.Timer
+	ttftTimer *time.Timer
+	gotToken  bool
+	stopTotal context.CancelFunc
+}
+
+// newWatchdog derives a context bounded by the configured timeouts. Zero
+// timeouts are disabled. Call stop when the stream ends.
+func newWatchdog(ctx context.Context, t StreamTimeouts) (context.Context, *watchdog) {
+	w := &watchdog{timeouts: t}
+	ctx, w.cancel = context.WithCancelCause(ctx)
+	w.stopTotal = func() {}
+	if t.Total > 0 {
+		ctx, w.stopTotal = context.WithTimeoutCause(ctx, t.Total,
+			&errors.StreamTimeoutError{Kind: errors.TotalTimeout, Timeout: t.Total})
+	}
+	return ctx, w
+}
+
+// attempt arms the first-token and idle timers for a connection attempt and
+// returns the func that disarms them when it ends.
+func (w *watchdog) attempt() (end func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if d := w.timeouts.FirstToken; d > 0 && !w.gotToken {
+		w.ttftTimer = time.AfterFunc(d, func() {
+			w.cancel(&errors.StreamTimeoutError{Kind: errors.FirstTokenTimeout, Timeout: d})
+		})
+	}
+	if d := w.timeouts.Idle; d > 0 {
+		w.idleTimer = time.AfterFunc(d, func() {
+			w.cancel(&errors.StreamTimeoutError{Kind: errors.IdleTimeout, Timeout: d})
+		})
+	}
+	return w.disarm
+}
+
+// disarm stops the first-token and idle timers.
+func (w *watchdog) disarm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.idleTimer != nil {
+		w.idleTimer.Stop()
+		w.idleTimer = nil
+	}
+	if w.ttftTimer != nil {
+		w.ttftTimer.Stop()
+		w.ttftTimer = nil
+	}
+}
+
+// activity records a line from the server; keep-alive comments count.
+func (w *watchdog) activity() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.idleTimer != nil {
+		w.idleTimer.Reset(w.timeouts.Idle)
+	}
+}
+
+// pause stops the idle timer while the consumer handles a chunk, so a slow
+// consumer is not taken for a silent server. The next activity re-arms it.
+func (w *watchdog) pause() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.idleTimer != nil {
+		w.idleTimer.Stop()
+	}
+}
+
+// firstToken records that content has started to arrive.
+func (w *watchdog) firstToken() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gotToken = true
+	if w.ttftTimer != nil {
+		w.ttftTimer.Stop()
+		w.ttftTimer = nil
+	}
+}
+
+// err maps a stream error to the timeout that caused it, if any.
+func (w *watchdog) err(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if cause := context.Cause(ctx); cause != nil && cause != ctx.Err() {
+		if _, ok := cause.(*errors.StreamTimeoutError); ok {
+			return cause
+		}
+	}
+	return err
+}
+
+func (w *watchdog) stop() {
+	w.disarm()
+	w.stopTotal()
+	w.cancel(nil)
+}
@@ -0,0 +1,92 @@
+package chat_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/errors"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+)
+
+func TestStreamTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   []openroutertest.Reply
+		opts    []chat.StreamOption
+		consume time.Duration
+		want    error
+	}{
+		{
+			name:  "first token late",
+			reply: []openroutertest.Reply{openroutertest.Text("slow reply").WithChunkDelay(time.Second)},
+			opts:  []chat.StreamOption{chat.WithFirstTokenTimeout(50 * time.Millisecond)},
+			want:  errors.ErrFirstTokenTimeout,
+		},
+		{
+			name: "idle mid-stream",
+			reply: []openroutertest.Reply{{Events: []openroutertest.Event{
+				openroutertest.Text("a").Events[0],
+				{Delay: time.Second, Raw: "data: [DONE]\n\n"},
+			}}},
+			opts: []chat.StreamOption{chat.WithIdleTimeout(50 * time.Millisecond)},
+			want: errors.ErrIdleTimeout,
+		},
+		{
+			name:  "total",
+			reply: []openroutertest.Reply{openroutertest.Text("a b c d e f").WithChunkDelay(30 * time.Millisecond)},
+			opts:  []chat.StreamOption{chat.WithTotalTimeout(100 * time.Millisecond), chat.WithIdleTimeout(time.Second)},
+			want:  errors.ErrTotalTimeout,
+		},
+		{
+			name:  "keep-alives count as activity",
+			reply: []openroutertest.Reply{keepAliveReply(6, 30*time.Millisecond)},
+			opts:  []chat.StreamOption{chat.WithIdleTimeout(80 * time.Millisecond)},
+		},
+		{
+			name:    "slow consumer is not idle",
+			reply:   []openroutertest.Reply{openroutertest.Text("a b c")},
+			opts:    []chat.StreamOption{chat.WithIdleTimeout(50 * time.Millisecond), chat.WithFirstTokenTimeout(50 * time.Millisecond)},
+			consume: 100 * time.Millisecond,
+		},
+		{
+			name: "retry wait does not count",
+			reply: []openroutertest.Reply{
+				openroutertest.RateLimited(200 * time.Millisecond),
+				openroutertest.Text("a b"),
+			},
+			opts: []chat.StreamOption{chat.WithFirstTokenTimeout(100 * time.Millisecond), chat.WithIdleTimeout(100 * time.Millisecond)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openroutertest.NewServer(t)
+			srv.Chat(tt.reply...)
+			client := srv.Client()
+			req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: "hi"}}}
+			err := client.Chat.CreateStreamFunc(context.Background(), req, func(*chat.StreamChunk) error {
+				time.Sleep(tt.consume)
+				return nil
+			}, tt.opts...)
+			if tt.want == nil && err != nil {
+				t.Fatalf("err = %v, want nil", err)
+			}
+			if tt.want != nil && !stderrors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// keepAliveReply streams one word after n keep-alive comments sent every d.
+func keepAliveReply(n int, d time.Duration) openroutertest.Reply {
+	r := openroutertest.Text("done")
+	events := make([]openroutertest.Event, 0, n+len(r.Events))
+	for range n {
+		events = append(events, openroutertest.Event{Delay: d, Raw: ": OPENROUTER PROCESSING\n\n"})
+	}
+	r.Events = append(events, r.Events...)
+	return r
+}
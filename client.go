@@ -11,7 +11,10 @@ import (
 	"github.com/MetaDiv-AI/openrouter/embeddings"
 	"github.com/MetaDiv-AI/openrouter/errors"
 	"github.com/MetaDiv-AI/openrouter/internal"
+	"github.com/MetaDiv-AI/openrouter/logging"
 	"github.com/MetaDiv-AI/openrouter/models"
+	"github.com/MetaDiv-AI/openrouter/observe"
+	"github.com/MetaDiv-AI/openrouter/retry"
 )
 
 // Client is the OpenRouter API client.
@@ -52,20 +55,44 @@ func NewClient(opts ...Option) (*Client, error) {
 	if cfg.Debug && cfg.Logger == nil {
 		cfg.Logger = logger.New().Development().Build()
 	}
+	if cfg.Logging != nil && cfg.Logger == nil {
+		cfg.Logger = logger.New().Build()
+	}
+	hooks := cfg.Hooks
+	if cfg.Logger != nil {
+		logCfg := logging.Config{}
+		if cfg.Debug {
+			logCfg.Body = logging.BodyTruncated
+		}
+		if cfg.Logging != nil {
+			logCfg = *cfg.Logging
+		}
+		hooks = append([]observe.Hooks{logging.New(cfg.Logger, logCfg)}, hooks...)
+	}
+
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = cfg.MaxRetries + 1
+	if cfg.RetryPolicy != nil {
+		policy = *cfg.RetryPolicy
+	}
 
-	caller := internal.NewCaller(
-		cfg.BaseURL,
-		apiKey,
-		cfg.Timeout,
-		cfg.Headers,
-		cfg.Logger,
-		cfg.MaxRetries,
-	)
+	caller := internal.NewCaller(internal.CallerConfig{
+		BaseURL:   cfg.BaseURL,
+		APIKey:    apiKey,
+		Timeout:   cfg.Timeout,
+		Headers:   cfg.Headers,
+		Retry:     policy,
+		Limiter:   cfg.RateLimiter,
+		Breaker:   cfg.Breaker,
+		Transport: cfg.Transport,
+		Hooks:     hooks,
+	})
 
+	streamDefaults := append([]chat.StreamOption{chat.WithIdleTimeout(DefaultStreamIdleTimeout)}, cfg.StreamOptions...)
 	modelsSvc := models.NewService(caller)
 	return &Client{
 		caller:     caller,
-		Chat:       chat.NewService(caller),
+		Chat:       chat.NewService(caller, streamDefaults...),
 		Embeddings: embeddings.NewService(caller),
 		Models:     modelsSvc,
 		Cost:       cost.NewService(modelsSvc),
@@ -1,26 +1,49 @@
 package openrouter
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/MetaDiv-AI/logger"
+	"github.com/MetaDiv-AI/openrouter/breaker"
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/logging"
+	"github.com/MetaDiv-AI/openrouter/observe"
+	"github.com/MetaDiv-AI/openrouter/ratelimit"
+	"github.com/MetaDiv-AI/openrouter/retry"
 )
 
 const (
 	DefaultBaseURL    = "https://openrouter.ai/api/v1"
 	DefaultTimeout    = 60 * time.Second
 	DefaultMaxRetries = 3
+
+	// DefaultStreamIdleTimeout is the default limit on the gap between stream
+	// events. OpenRouter sends keep-alive comments well within it.
+	DefaultStreamIdleTimeout = 2 * time.Minute
 )
 
 // Config holds the client configuration.
+// RetryPolicy, when set, takes precedence over MaxRetries.
 type Config struct {
-	APIKey     string
-	BaseURL    string
-	Timeout    time.Duration
-	MaxRetries int
-	Headers    map[string]string
-	Debug      bool
-	Logger     logger.Logger
+	APIKey      string
+	BaseURL     string
+	Timeout     time.Duration
+	MaxRetries  int
+	RetryPolicy *retry.Policy
+	RateLimiter *ratelimit.Limiter
+	Breaker     *breaker.Breaker
+	// StreamOptions are defaults for every CreateStream call.
+	StreamOptions []chat.StreamOption
+	// Transport, when set, sends every request, streams included.
+	Transport http.RoundTripper
+	// Hooks observe every operation; see WithHooks.
+	Hooks   []observe.Hooks
+	Headers map[string]string
+	Debug   bool
+	Logger  logger.Logger
+	// Logging, when set, configures request logging; see WithLogging.
+	Logging *logging.Config
 }
 
 // Option is a functional option for configuring the client.
@@ -40,7 +63,9 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
-// WithTimeout sets the HTTP client timeout.
+// WithTimeout sets the HTTP client timeout for unary requests. It does not
+// apply to streams, which are bounded by chat.StreamTimeouts instead (see
+// WithStreamOptions and DefaultStreamIdleTimeout).
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Config) {
 		c.Timeout = timeout
@@ -54,6 +79,54 @@ func WithMaxRetries(n int) Option {
 	}
 }
 
+// WithRetryPolicy sets the retry policy used for all requests. Individual calls
+// can override it with retry.WithPolicy on their context.
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Config) {
+		c.RetryPolicy = &p
+	}
+}
+
+// WithRateLimiter sets a client-side rate limiter respected by every service,
+// including batch runs. A Limiter may be shared between clients using the same key.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(c *Config) {
+		c.RateLimiter = l
+	}
+}
+
+// WithCircuitBreaker sets a circuit breaker keyed by model and provider. Requests
+// for a model whose circuit is open fail fast with *errors.CircuitOpenError.
+func WithCircuitBreaker(b *breaker.Breaker) Option {
+	return func(c *Config) {
+		c.Breaker = b
+	}
+}
+
+// WithStreamOptions sets default options, such as chat.WithStreamTimeouts,
+// applied to every CreateStream call before the call's own options.
+func WithStreamOptions(opts ...chat.StreamOption) Option {
+	return func(c *Config) {
+		c.StreamOptions = append(c.StreamOptions, opts...)
+	}
+}
+
+// WithTransport sets the http.RoundTripper used for all requests, e.g. a
+// cassette.Recorder or a proxy-aware transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Config) {
+		c.Transport = rt
+	}
+}
+
+// WithHooks registers observers of client operations, such as the tracing
+// and metrics integrations. It may be given more than once.
+func WithHooks(hooks ...observe.Hooks) Option {
+	return func(c *Config) {
+		c.Hooks = append(c.Hooks, hooks...)
+	}
+}
+
 // WithHeaders sets custom headers merged with defaults.
 func WithHeaders(headers map[string]string) Option {
 	return func(c *Config) {
@@ -81,16 +154,27 @@ func WithForwardedFor(ip string) Option {
 	return WithHeaders(map[string]string{"X-Forwarded-For": ip})
 }
 
-// WithDebug enables debug logging. Uses logger.New().Development().Build() if Logger is nil.
+// WithDebug enables request logging with truncated, redacted bodies. Uses
+// logger.New().Development().Build() if Logger is nil.
 func WithDebug(debug bool) Option {
 	return func(c *Config) {
 		c.Debug = debug
 	}
 }
 
-// WithLogger sets a custom logger (used by http_caller.WithDebugLogger).
+// WithLogger sets the logger for per-request log records (see the logging
+// package). Without WithDebug or WithLogging only metadata is logged.
 func WithLogger(log logger.Logger) Option {
 	return func(c *Config) {
 		c.Logger = log
 	}
 }
+
+// WithLogging configures per-request logging: body level, truncation,
+// redaction rules and sampling. It overrides the defaults of WithDebug.
+// Uses logger.New().Build() if Logger is nil.
+func WithLogging(cfg logging.Config) Option {
+	return func(c *Config) {
+		c.Logging = &cfg
+	}
+}
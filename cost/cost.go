@@ -10,33 +10,22 @@ import (
 
 // Service provides cost estimation.
 type Service struct {
-	models *models.Service
+	models models.Catalog
 }
 
-// NewService creates a new cost service that uses the models service for model data.
-func NewService(modelsService *models.Service) *Service {
+// NewService creates a new cost service that uses the models catalog for model data.
+func NewService(catalog models.Catalog) *Service {
 	return &Service{
-		models: modelsService,
+		models: catalog,
 	}
 }
 
 // Estimate computes the estimated cost for a model and token counts.
 func (s *Service) Estimate(ctx context.Context, modelID string, inputTokens, outputTokens int) (float64, error) {
-	list, err := s.models.List(ctx)
+	m, err := s.models.Get(ctx, modelID)
 	if err != nil {
 		return 0, err
 	}
-
-	var m *models.Model
-	for i := range list {
-		if list[i].ID == modelID {
-			m = &list[i]
-			break
-		}
-	}
-	if m == nil {
-		return 0, errors.ErrModelNotFound
-	}
 	if m.Pricing == nil {
 		return 0, errors.ErrPricingUnavailable
 	}
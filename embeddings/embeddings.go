@@ -5,8 +5,17 @@ import (
 
 	"github.com/MetaDiv-AI/openrouter/errors"
 	"github.com/MetaDiv-AI/openrouter/internal"
+	"github.com/MetaDiv-AI/openrouter/observe"
+	"github.com/MetaDiv-AI/openrouter/provider"
 )
 
+// Client is the embeddings API. *Service implements it.
+type Client interface {
+	Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error)
+}
+
+var _ Client = (*Service)(nil)
+
 // Service provides embedding operations.
 type Service struct {
 	caller *internal.Caller
@@ -17,10 +26,26 @@ func NewService(caller *internal.Caller) *Service {
 	return &Service{caller: caller}
 }
 
+// Encoding formats for CreateRequest.EncodingFormat.
+const (
+	EncodingFloat  = "float"
+	EncodingBase64 = "base64"
+)
+
 // CreateRequest is the request for creating embeddings.
 type CreateRequest struct {
 	Model string `json:"model"`
 	Input any    `json:"input"` // string or []string
+	// Dimensions truncates the embeddings, for models that support it.
+	Dimensions *int `json:"dimensions,omitempty"`
+	// EncodingFormat is EncodingFloat (the default) or EncodingBase64, which
+	// is several times smaller on the wire. Either decodes into Vector.
+	EncodingFormat string `json:"encoding_format,omitempty"`
+	// InputType tells models that distinguish them what the input is for,
+	// e.g. "search_query" or "search_document".
+	InputType string                        `json:"input_type,omitempty"`
+	Provider  *provider.ProviderPreferences `json:"provider,omitempty"`
+	User      string                        `json:"user,omitempty"`
 }
 
 // CreateResponse is the response from creating embeddings.
@@ -31,9 +56,9 @@ type CreateResponse struct {
 
 // EmbeddingData holds a single embedding.
 type EmbeddingData struct {
-	Object    string    `json:"object"`
-	Embedding []float64 `json:"embedding"`
-	Index     int       `json:"index"`
+	Object    string `json:"object"`
+	Embedding Vector `json:"embedding"`
+	Index     int    `json:"index"`
 }
 
 // Usage represents token usage.
@@ -48,9 +73,36 @@ func (s *Service) Create(ctx context.Context, req *CreateRequest) (*CreateRespon
 		return nil, &errors.OpenRouterError{Code: 400, Message: "request cannot be nil"}
 	}
 
+	ctx = internal.WithRequestInfo(ctx, internal.RequestInfo{
+		Model:           req.Model,
+		EstimatedTokens: estimateTokens(req.Input),
+	})
+
+	ctx, op := s.caller.StartOp(ctx, observe.Operation{Name: observe.OpEmbeddings, Endpoint: "/embeddings", Model: req.Model, Request: req})
+
 	var resp CreateResponse
 	if err := s.caller.DoPost(ctx, "/embeddings", req, &resp); err != nil {
+		op.End(observe.Result{Err: err})
 		return nil, err
 	}
+	res := observe.Result{Model: req.Model, Response: &resp}
+	if resp.Usage != nil {
+		res.InputTokens = resp.Usage.PromptTokens
+	}
+	op.End(res)
 	return &resp, nil
 }
+
+// estimateTokens gives a rough token count of the input for rate limiting.
+func estimateTokens(input any) int {
+	chars := 0
+	switch in := input.(type) {
+	case string:
+		chars = len(in)
+	case []string:
+		for _, s := range in {
+			chars += len(s)
+		}
+	}
+	return internal.EstimateTokens(chars)
+}
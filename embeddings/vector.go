@@ -0,0 +1,71 @@
+package embeddings
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Vector is an embedding. It decodes from a JSON array of numbers or, with
+// EncodingBase64, from a base64 string of little-endian float32 values, and
+// encodes as an array.
+type Vector []float32
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return json.Unmarshal(data, (*[]float32)(v))
+	}
+	src := data[1 : len(data)-1]
+	if bytes.IndexByte(src, '\\') >= 0 {
+		// Escaped, e.g. "\/": let encoding/json unquote it first.
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		src = []byte(s)
+	}
+	out, err := decodeBase64(src)
+	if err != nil {
+		return err
+	}
+	*v = out
+	return nil
+}
+
+// decodeBase64 decodes little-endian float32 values from base64 through a
+// fixed buffer. Line breaks are skipped and padding is optional.
+func decodeBase64(src []byte) (Vector, error) {
+	src = bytes.TrimRight(src, "=\r\n")
+	dec := base64.NewDecoder(base64.RawStdEncoding, bytes.NewReader(src))
+	var buf [3072]byte // a whole number of float32 values
+	out := make(Vector, 0, base64.RawStdEncoding.DecodedLen(len(src))/4)
+	for {
+		m, err := io.ReadFull(dec, buf[:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("embeddings: decode base64 vector: %w", err)
+		}
+		if m%4 != 0 {
+			return nil, fmt.Errorf("embeddings: base64 vector of %d bytes is not float32 values", 4*len(out)+m)
+		}
+		for i := 0; i < m; i += 4 {
+			out = append(out, math.Float32frombits(binary.LittleEndian.Uint32(buf[i:])))
+		}
+		if err != nil {
+			return out, nil
+		}
+	}
+}
+
+// Base64 returns v in the EncodingBase64 wire format.
+func (v Vector) Base64() string {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
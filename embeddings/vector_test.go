@@ -0,0 +1,62 @@
+package embeddings_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MetaDiv-AI/openrouter/embeddings"
+)
+
+func TestVectorUnmarshal(t *testing.T) {
+	small := embeddings.Vector{1, -0.5, 0.25}
+	large := make(embeddings.Vector, 3000) // over 4096 base64 characters
+	for i := range large {
+		large[i] = float32(i) / 7
+	}
+	// wrap breaks s into lines of n characters, as MIME encoders do.
+	wrap := func(s, sep string, n int) string {
+		var b strings.Builder
+		for len(s) > n {
+			b.WriteString(s[:n] + sep)
+			s = s[n:]
+		}
+		b.WriteString(s)
+		return b.String()
+	}
+	quote := func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+
+	tests := []struct {
+		name    string
+		json    string
+		want    embeddings.Vector
+		wantErr bool
+	}{
+		{"array", `[1,-0.5,0.25]`, small, false},
+		{"base64", quote(small.Base64()), small, false},
+		{"unpadded", quote(strings.TrimRight(small.Base64(), "=")), small, false},
+		{"escaped", strings.ReplaceAll(quote(large.Base64()), "/", `\/`), large, false},
+		{"large", quote(large.Base64()), large, false},
+		{"line breaks", quote(wrap(large.Base64(), "\n", 76)), large, false},
+		{"crlf line breaks", quote(wrap(large.Base64(), "\r\n", 76)), large, false},
+		{"empty", `""`, embeddings.Vector{}, false},
+		{"not float32 values", quote("AAAA"), nil, true},
+		{"invalid", quote("AA!A"), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got embeddings.Vector
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("got %d values %v..., want %d", len(got), got[:min(3, len(got))], len(tt.want))
+			}
+		})
+	}
+}
@@ -15,14 +15,20 @@
 //	}
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"time"
+)
 
 // OpenRouterError represents an error returned by the OpenRouter API.
+// RetryAfter holds the wait requested by a Retry-After response header, if any.
 type OpenRouterError struct {
 	HTTPStatus int
 	Code       int
 	Message    string
 	Metadata   map[string]any
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface.
@@ -33,10 +39,12 @@ func (e *OpenRouterError) Error() string {
 	return fmt.Sprintf("openrouter: API error (code: %d)", e.Code)
 }
 
-// Retryable returns true if the error is transient and the request can be retried.
+// Retryable returns true if the error is transient and the request can be retried:
+// timeouts, rate limits, server errors, provider errors (502) and edge timeouts
+// or overload (524, 529).
 func (e *OpenRouterError) Retryable() bool {
 	switch e.Code {
-	case 429, 503, 408:
+	case 408, 429, 500, 502, 503, 504, 524, 529:
 		return true
 	default:
 		return false
@@ -62,5 +70,69 @@ var (
 	ErrModeration          = &OpenRouterError{Code: 403}
 	ErrTimeout             = &OpenRouterError{Code: 408}
 	ErrServiceUnavailable  = &OpenRouterError{Code: 503}
+	ErrServer              = &OpenRouterError{Code: 500}
+	ErrGatewayTimeout      = &OpenRouterError{Code: 504}
+	ErrEdgeTimeout         = &OpenRouterError{Code: 524}
+	ErrOverloaded          = &OpenRouterError{Code: 529}
 	ErrPricingUnavailable  = &OpenRouterError{Code: 404, Message: "pricing not available for model"}
 )
+
+// CircuitOpenError is returned without contacting the API when the circuit
+// breaker for a model is open. Until is when the circuit will next admit a probe.
+type CircuitOpenError struct {
+	Key   string
+	Until time.Time
+}
+
+// Error implements the error interface.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("openrouter: circuit open for %s", e.Key)
+}
+
+// Is reports whether target is a CircuitOpenError, so errors.Is(err, ErrCircuitOpen) works for any key.
+func (e *CircuitOpenError) Is(target error) bool {
+	_, ok := target.(*CircuitOpenError)
+	return ok
+}
+
+// ErrCircuitOpen matches any CircuitOpenError.
+var ErrCircuitOpen = &CircuitOpenError{}
+
+// StreamTimeoutKind identifies which stream timeout expired.
+type StreamTimeoutKind string
+
+const (
+	FirstTokenTimeout StreamTimeoutKind = "first token"
+	IdleTimeout       StreamTimeoutKind = "idle"
+	TotalTimeout      StreamTimeoutKind = "total"
+)
+
+// StreamTimeoutError is returned when a stream exceeds one of its timeouts.
+type StreamTimeoutError struct {
+	Kind    StreamTimeoutKind
+	Timeout time.Duration
+}
+
+// Error implements the error interface.
+func (e *StreamTimeoutError) Error() string {
+	return fmt.Sprintf("openrouter: stream %s timeout after %s", e.Kind, e.Timeout)
+}
+
+// Is reports whether target is a StreamTimeoutError of the same kind. A target
+// without a kind matches any stream timeout.
+func (e *StreamTimeoutError) Is(target error) bool {
+	t, ok := target.(*StreamTimeoutError)
+	return ok && (t.Kind == "" || t.Kind == e.Kind)
+}
+
+// Stream timeout sentinels for errors.Is.
+var (
+	ErrStreamTimeout     = &StreamTimeoutError{}
+	ErrFirstTokenTimeout = &StreamTimeoutError{Kind: FirstTokenTimeout}
+	ErrIdleTimeout       = &StreamTimeoutError{Kind: IdleTimeout}
+	ErrTotalTimeout      = &StreamTimeoutError{Kind: TotalTimeout}
+)
+
+// ErrSlowConsumer ends a stream consumer that fell behind under the fail
+// backpressure policy.
+var ErrSlowConsumer = stderrors.New("openrouter: stream consumer too slow")
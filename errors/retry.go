@@ -6,7 +6,8 @@ import (
 )
 
 // Retryable returns true if the given error indicates a transient failure
-// that may succeed on retry (429, 503, 408, or context.DeadlineExceeded).
+// that may succeed on retry (see OpenRouterError.Retryable, or context.DeadlineExceeded).
+// The client's retry behavior is configured with the retry package.
 func Retryable(err error) bool {
 	if err == nil {
 		return false
@@ -5,9 +5,10 @@ go 1.23.2
 require (
 	github.com/MetaDiv-AI/http_caller v1.0.0
 	github.com/MetaDiv-AI/logger v1.0.0
+	go.uber.org/zap v1.27.1
 )
 
 require (
+	github.com/stretchr/testify v1.10.0 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
-	go.uber.org/zap v1.27.1 // indirect
 )
@@ -3,42 +3,85 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/MetaDiv-AI/http_caller"
-	"github.com/MetaDiv-AI/logger"
+	"github.com/MetaDiv-AI/openrouter/breaker"
 	"github.com/MetaDiv-AI/openrouter/errors"
+	"github.com/MetaDiv-AI/openrouter/observe"
+	"github.com/MetaDiv-AI/openrouter/ratelimit"
+	"github.com/MetaDiv-AI/openrouter/retry"
 )
 
+// CallerConfig holds the settings a Caller is built from.
+type CallerConfig struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+	Headers map[string]string
+	Retry   retry.Policy
+	Limiter *ratelimit.Limiter
+	Breaker *breaker.Breaker
+	// Transport sends requests, streams included; http.DefaultTransport if nil.
+	Transport http.RoundTripper
+	Hooks     []observe.Hooks
+}
+
 // Caller wraps http_caller with auth, headers, retry, and error parsing.
 type Caller struct {
 	baseURL string
 	apiKey  string
 	headers map[string]string
 	client  *http.Client
-	logger  logger.Logger
-	retries int
+	// streamClient has no overall timeout: streams are bounded by their own
+	// timeouts in the chat package.
+	streamClient *http.Client
+	retry        retry.Policy
+	limiter      *ratelimit.Limiter
+	breaker      *breaker.Breaker
+	hooks        []observe.Hooks
 }
 
 // NewCaller creates a new Caller with the given configuration.
-func NewCaller(baseURL, apiKey string, timeout time.Duration, headers map[string]string, log logger.Logger, retries int) *Caller {
-	baseURL = strings.TrimSuffix(baseURL, "/")
+func NewCaller(cfg CallerConfig) *Caller {
+	headers := cfg.Headers
 	if headers == nil {
 		headers = make(map[string]string)
 	}
+	transport := cfg.Transport
+	if len(cfg.Hooks) > 0 {
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		transport = &observingTransport{base: transport, hooks: cfg.Hooks}
+	}
 	return &Caller{
-		baseURL: baseURL,
-		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
 		headers: copyHeaders(headers),
 		client: &http.Client{
-			Timeout: timeout,
+			Timeout:   cfg.Timeout,
+			Transport: transport,
 		},
-		logger:  log,
-		retries: retries,
+		streamClient: &http.Client{Transport: transport},
+		retry:        cfg.Retry,
+		limiter:      cfg.Limiter,
+		breaker:      cfg.Breaker,
+		hooks:        cfg.Hooks,
+	}
+}
+
+// retryPolicy returns the policy attached to ctx, or the caller's default.
+func (c *Caller) retryPolicy(ctx context.Context) retry.Policy {
+	if p, ok := retry.FromContext(ctx); ok {
+		return p
 	}
+	return c.retry
 }
 
 func copyHeaders(m map[string]string) map[string]string {
@@ -49,6 +92,72 @@ func copyHeaders(m map[string]string) map[string]string {
 	return out
 }
 
+// wait blocks until the rate limiter, if any, admits the request described by ctx.
+func (c *Caller) wait(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	info := RequestInfoFrom(ctx)
+	return c.limiter.Wait(ctx, info.Model, info.EstimatedTokens)
+}
+
+// observe reports an API error to the rate limiter and returns it.
+func (c *Caller) observe(err *errors.OpenRouterError) error {
+	if c.limiter != nil {
+		c.limiter.Observe(err)
+	}
+	return err
+}
+
+// allow checks the circuit breaker for the model of the request described by ctx.
+// The returned done func reports the attempt's outcome and is never nil.
+func (c *Caller) allow(ctx context.Context) (done func(error), err error) {
+	model := RequestInfoFrom(ctx).Model
+	if c.breaker == nil || model == "" {
+		return func(error) {}, nil
+	}
+	record, err := c.breaker.Allow(breaker.ModelKey(model))
+	if err != nil {
+		return nil, err
+	}
+	return func(err error) {
+		if err != nil && ctx.Err() != nil {
+			// A stream timeout cancels the context too, but it is the model
+			// stalling and counts against it. Any other cancellation or
+			// deadline is the caller's own and is reported as such, however
+			// the transport wrapped it, so it does not.
+			var terr *errors.StreamTimeoutError
+			if stderrors.As(context.Cause(ctx), &terr) {
+				err = terr
+			} else {
+				err = ctx.Err()
+			}
+		}
+		record(err)
+	}, nil
+}
+
+// recordProvider feeds the provider circuit from a response body or error metadata.
+func (c *Caller) recordProvider(body []byte, err error) {
+	if c.breaker == nil {
+		return
+	}
+	var provider string
+	var oerr *errors.OpenRouterError
+	if stderrors.As(err, &oerr) {
+		provider, _ = oerr.Metadata["provider_name"].(string)
+	} else if err == nil && len(body) > 0 {
+		var meta struct {
+			Provider string `json:"provider"`
+		}
+		_ = json.Unmarshal(body, &meta)
+		provider = meta.Provider
+	}
+	if provider != "" {
+		c.breaker.Record(breaker.ProviderKey(provider), err)
+	}
+}
+
 // errorResponse is the shape of OpenRouter error responses.
 type errorResponse struct {
 	Error struct {
@@ -58,13 +167,15 @@ type errorResponse struct {
 	} `json:"error"`
 }
 
-func parseError(statusCode int, rawBody string) *errors.OpenRouterError {
+func parseError(statusCode int, header http.Header, rawBody string) *errors.OpenRouterError {
+	retryAfter := parseRetryAfter(header.Get("Retry-After"))
 	var resp errorResponse
 	if err := json.Unmarshal([]byte(rawBody), &resp); err != nil {
 		return &errors.OpenRouterError{
 			HTTPStatus: statusCode,
 			Code:       statusCode,
 			Message:    rawBody,
+			RetryAfter: retryAfter,
 		}
 	}
 	code := resp.Error.Code
@@ -76,108 +187,84 @@ func parseError(statusCode int, rawBody string) *errors.OpenRouterError {
 		Code:       code,
 		Message:    resp.Error.Message,
 		Metadata:   resp.Error.Metadata,
+		RetryAfter: retryAfter,
 	}
 }
 
+// parseRetryAfter reads a Retry-After value given in seconds or as an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // DoPost executes a POST request with retries and error mapping.
 func (c *Caller) DoPost(ctx context.Context, path string, req, resp any) error {
-	url := c.baseURL + path
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		return err
 	}
 	reqBody := json.RawMessage(reqBytes)
-
-	var lastResp *http_caller.Response[json.RawMessage]
-	err = Do(ctx, c.retries, DefaultBackoff, func() error {
-		builder := http_caller.New[json.RawMessage, json.RawMessage](url).
-			Header("Authorization", "Bearer "+c.apiKey).
-			Headers(c.headers).
-			Body(&reqBody).
-			WithClient(c.client)
-		if c.logger != nil {
-			builder = builder.WithDebugLogger(c.logger)
-		}
-
-		r, doErr := builder.Post(ctx)
-		if doErr != nil {
-			return doErr
-		}
-		lastResp = r
-
-		if r.StatusCode >= 400 {
-			return parseError(r.StatusCode, r.RawBody)
-		}
-		return nil
+	return c.do(ctx, false, resp, func() (*http_caller.Response[json.RawMessage], error) {
+		return c.builder(path).Body(&reqBody).Post(ctx)
 	})
-	if err != nil {
-		return err
-	}
-	if lastResp == nil {
-		return nil
-	}
-	if resp != nil && len(lastResp.Body) > 0 {
-		return json.Unmarshal(lastResp.Body, resp)
-	}
-	return nil
 }
 
 // DoGet executes a GET request with retries.
 func (c *Caller) DoGet(ctx context.Context, path string, resp any) error {
-	url := c.baseURL + path
+	return c.do(ctx, true, resp, func() (*http_caller.Response[json.RawMessage], error) {
+		return c.builder(path).Get(ctx)
+	})
+}
 
-	var lastResp *http_caller.Response[json.RawMessage]
-	err := Do(ctx, c.retries, DefaultBackoff, func() error {
-		builder := http_caller.New[struct{}, json.RawMessage](url).
-			Header("Authorization", "Bearer "+c.apiKey).
-			Headers(c.headers).
-			WithClient(c.client)
-		if c.logger != nil {
-			builder = builder.WithDebugLogger(c.logger)
-		}
+// builder returns an http_caller builder for path with auth and headers set.
+// Requests are logged by the logging hooks rather than http_caller, which
+// would log full bodies.
+func (c *Caller) builder(path string) *http_caller.Builder[json.RawMessage, json.RawMessage] {
+	return http_caller.New[json.RawMessage, json.RawMessage](c.baseURL+path).
+		Header("Authorization", "Bearer "+c.apiKey).
+		Headers(c.headers).
+		WithClient(c.client)
+}
 
-		r, doErr := builder.Get(ctx)
-		if doErr != nil {
-			return doErr
+// do runs send under the retry policy, rate limiter and circuit breaker, and
+// decodes the final response body into resp.
+func (c *Caller) do(ctx context.Context, idempotent bool, resp any, send func() (*http_caller.Response[json.RawMessage], error)) error {
+	var body json.RawMessage
+	err := retry.Do(ctx, c.retryPolicy(ctx), idempotent, func() error {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		done, err := c.allow(ctx)
+		if err != nil {
+			return err
 		}
-		lastResp = r
 
-		if r.StatusCode >= 400 {
-			return parseError(r.StatusCode, r.RawBody)
+		r, err := send()
+		// Error pages (e.g. an HTML 524 from the edge) fail to unmarshal, so
+		// check the status before the decode error.
+		if r != nil && r.StatusCode >= 400 {
+			err = c.observe(parseError(r.StatusCode, r.Headers, r.RawBody))
+		} else if err == nil {
+			body = r.Body
 		}
-		return nil
-	})
-	if err != nil {
+		done(err)
+		c.recordProvider(body, err)
 		return err
-	}
-	if lastResp == nil {
-		return nil
-	}
-	if resp != nil && len(lastResp.Body) > 0 {
-		return json.Unmarshal(lastResp.Body, resp)
-	}
-	return nil
-}
-
-// DoStreamPost executes a streaming POST request (no retry).
-func (c *Caller) DoStreamPost(ctx context.Context, path string, req any, handler http_caller.ChunkHandler) error {
-	url := c.baseURL + path
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
+	})
+	if err != nil || resp == nil || len(body) == 0 {
 		return err
 	}
-	reqBody := json.RawMessage(reqBytes)
-
-	builder := http_caller.New[json.RawMessage, any](url).
-		Header("Authorization", "Bearer "+c.apiKey).
-		Headers(c.headers).
-		Body(&reqBody).
-		WithClient(c.client)
-	if c.logger != nil {
-		builder = builder.WithDebugLogger(c.logger)
-	}
-
-	return builder.StreamPost(ctx, handler)
+	return json.Unmarshal(body, resp)
 }
 
 // redactAPIKey shows only the first 7 chars (e.g. "sk-...") for safe display.
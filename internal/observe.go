@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/observe"
+)
+
+// Op tracks one observed operation. A nil *Op, returned when no hooks are
+// registered, ignores all calls.
+type Op struct {
+	hooks []observe.Hooks
+	ctx   context.Context
+	op    observe.Operation
+
+	mu         sync.Mutex
+	attempts   int
+	status     int
+	firstToken time.Duration
+	ended      bool
+}
+
+type opKey struct{}
+
+// StartOp begins an observed operation and returns the context to run it with.
+func (c *Caller) StartOp(ctx context.Context, op observe.Operation) (context.Context, *Op) {
+	if len(c.hooks) == 0 {
+		return ctx, nil
+	}
+	op.Start = time.Now()
+	for _, h := range c.hooks {
+		if h.OnStart != nil {
+			ctx = h.OnStart(ctx, op)
+		}
+	}
+	o := &Op{hooks: c.hooks, op: op}
+	ctx = context.WithValue(ctx, opKey{}, o)
+	o.ctx = ctx
+	return ctx, o
+}
+
+// FirstToken reports the first content chunk of a stream. Later calls are ignored.
+func (o *Op) FirstToken() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	if o.firstToken != 0 {
+		o.mu.Unlock()
+		return
+	}
+	o.firstToken = time.Since(o.op.Start)
+	ttft := o.firstToken
+	o.mu.Unlock()
+	for _, h := range o.hooks {
+		if h.OnFirstToken != nil {
+			h.OnFirstToken(o.ctx, o.op, ttft)
+		}
+	}
+}
+
+// End reports the outcome. The attempt count, status, time to first token and
+// duration are filled in. Later calls are ignored.
+func (o *Op) End(res observe.Result) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	if o.ended {
+		o.mu.Unlock()
+		return
+	}
+	o.ended = true
+	res.Attempts = o.attempts
+	res.StatusCode = o.status
+	res.TimeToFirstToken = o.firstToken
+	o.mu.Unlock()
+	res.Duration = time.Since(o.op.Start)
+	for i := len(o.hooks) - 1; i >= 0; i-- {
+		if h := o.hooks[i]; h.OnEnd != nil {
+			h.OnEnd(o.ctx, o.op, res)
+		}
+	}
+}
+
+// attempt records an HTTP attempt and returns its number.
+func (o *Op) attempt(status int) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attempts++
+	o.status = status
+	return o.attempts
+}
+
+// observingTransport calls the hooks around each round trip.
+type observingTransport struct {
+	base  http.RoundTripper
+	hooks []observe.Hooks
+}
+
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	// RoundTrippers must not modify the request; hooks may add headers.
+	req = req.Clone(ctx)
+	for _, h := range t.hooks {
+		if h.OnRequest != nil {
+			h.OnRequest(ctx, req)
+		}
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	o, _ := ctx.Value(opKey{}).(*Op)
+	if o == nil {
+		return resp, err
+	}
+	a := observe.Attempt{Request: req, Duration: time.Since(start), Err: err}
+	if resp != nil {
+		a.StatusCode = resp.StatusCode
+		a.Header = resp.Header
+	}
+	a.Number = o.attempt(a.StatusCode)
+	for _, h := range t.hooks {
+		if h.OnAttempt != nil {
+			h.OnAttempt(ctx, o.op, a)
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,28 @@
+package internal
+
+import "context"
+
+// RequestInfo describes the API call being made, for limiting and accounting.
+// Services attach it to the context before calling the Caller.
+type RequestInfo struct {
+	Model           string
+	EstimatedTokens int
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying info.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the RequestInfo attached to ctx, or the zero value.
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// EstimateTokens roughly converts a character count into tokens (~4 characters per token).
+func EstimateTokens(chars int) int {
+	return (chars + 3) / 4
+}
@@ -1,24 +1,33 @@
 package internal
 
-import "bytes"
+import (
+	"bytes"
 
-// ParseSSELine extracts the JSON payload from an SSE "data:" line.
+	"github.com/MetaDiv-AI/openrouter/sse"
+)
+
+// ParseSSELine extracts the JSON payload from a single SSE "data:" line.
 // Returns (payload, true) if the line contains "[DONE]", (payload, false) otherwise.
-// Skips comment lines (starting with ":") and empty lines.
+// Skips comment lines (starting with ":"), empty lines and other fields.
+// Streams are decoded with sse.Decoder; this is kept for line-based callers.
 func ParseSSELine(line []byte) ([]byte, bool) {
-	line = bytes.TrimSpace(line)
-	if len(line) == 0 {
-		return nil, false
-	}
-	if line[0] == ':' {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) == 0 || line[0] == ':' {
 		return nil, false
 	}
-	if !bytes.HasPrefix(line, []byte("data: ")) {
+	field, value := sse.ParseLine(line)
+	if !bytes.Equal(field, []byte("data")) {
 		return nil, false
 	}
-	payload := bytes.TrimSpace(line[6:])
-	if bytes.Equal(payload, []byte("[DONE]")) {
+	return ParseData(value)
+}
+
+// ParseData interprets the data of an SSE event: it returns (nil, true) for
+// the "[DONE]" sentinel and the trimmed payload otherwise.
+func ParseData(data []byte) ([]byte, bool) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("[DONE]")) {
 		return nil, true
 	}
-	return payload, false
+	return data, false
 }
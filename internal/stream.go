@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/MetaDiv-AI/openrouter/retry"
+	"github.com/MetaDiv-AI/openrouter/sse"
+)
+
+const maxErrorBodySize = 64 * 1024
+
+// decoders pools SSE decoders, with the read and line buffers they have
+// grown, across streams.
+var decoders = sync.Pool{
+	New: func() any {
+		dec := sse.NewDecoder(nil)
+		dec.EmitComments()
+		return dec
+	},
+}
+
+// EventHandler receives each event of a stream, including comments.
+type EventHandler func(ev sse.Event) error
+
+// AttemptHook is called as each connection attempt of a stream is sent, and
+// the func it returns when the attempt ends. Retry backoff, Retry-After and
+// rate limiter waits fall between attempts.
+type AttemptHook func() (end func())
+
+// DoStreamPost executes a streaming POST request and passes each server-sent
+// event, comments included, to handler. The request is retried under the retry
+// policy until the first data event has been handed to handler; errors after
+// that are returned as is. attempt, if not nil, brackets each connection
+// attempt.
+func (c *Caller) DoStreamPost(ctx context.Context, path string, req any, attempt AttemptHook, handler EventHandler) error {
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	delivered := false
+	return retry.Do(ctx, c.retryPolicy(ctx), false, func() error {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		done, err := c.allow(ctx)
+		if err != nil {
+			return err
+		}
+
+		end := func() {}
+		if attempt != nil {
+			end = attempt()
+		}
+		err = c.stream(ctx, path, reqBytes, func(ev sse.Event) error {
+			if !ev.IsComment() {
+				delivered = true
+			}
+			return handler(ev)
+		})
+		end()
+		done(err)
+		c.recordProvider(nil, err)
+		if delivered {
+			return retry.Stop(err)
+		}
+		return err
+	})
+}
+
+// stream sends one streaming request and decodes the response as server-sent
+// events. Error statuses are mapped to *errors.OpenRouterError like unary calls.
+func (c *Caller) stream(ctx context.Context, path string, body []byte, handler EventHandler) error {
+	url := c.baseURL + path
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.streamClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return c.observe(parseError(resp.StatusCode, resp.Header, string(raw)))
+	}
+
+	dec := decoders.Get().(*sse.Decoder)
+	dec.Reset(resp.Body)
+	defer func() {
+		dec.Reset(nil)
+		decoders.Put(dec)
+	}()
+	for {
+		ev, err := dec.Next()
+		if stderrors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := handler(ev); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package logging writes one structured log record per client operation:
+// request id, model, status, latency, tokens and retries, and optionally the
+// request and response bodies with secrets and PII redacted and large base64
+// payloads elided.
+//
+// openrouter.WithLogger installs it with metadata-only records, and
+// WithDebug with truncated bodies; use openrouter.WithLogging for full
+// control.
+package logging
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"math/rand/v2"
+
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+
+	"github.com/MetaDiv-AI/openrouter/observe"
+)
+
+// BodyLevel selects how much of the request and response bodies is logged.
+type BodyLevel int
+
+const (
+	// BodyNone logs metadata only.
+	BodyNone BodyLevel = iota
+	// BodyTruncated logs bodies with base64 payloads elided and long strings,
+	// arrays and bodies cut to the configured limits.
+	BodyTruncated
+	// BodyFull logs bodies whole. Redaction rules still apply.
+	BodyFull
+)
+
+// Default limits for BodyTruncated.
+const (
+	DefaultMaxBodyBytes = 8 * 1024
+	DefaultMaxString    = 512
+	DefaultMaxArray     = 16
+)
+
+// Config configures the logging hooks. The zero value logs metadata for every
+// operation with DefaultRules.
+type Config struct {
+	// Body selects body logging.
+	Body BodyLevel
+	// MaxBodyBytes, MaxString and MaxArray limit logged bodies, strings and
+	// arrays at BodyTruncated. Zero values use the defaults.
+	MaxBodyBytes int
+	MaxString    int
+	MaxArray     int
+	// Rules redact matches in logged strings. Nil uses DefaultRules; an empty
+	// slice disables pattern redaction.
+	Rules []Rule
+	// SensitiveKeys are JSON object keys whose values are always replaced.
+	// Nil uses DefaultSensitiveKeys.
+	SensitiveKeys []string
+	// SampleRate is the fraction of successful operations logged, between 0
+	// and 1. Zero logs all of them. Failed operations are always logged.
+	SampleRate float64
+}
+
+type requestIDKey struct{}
+
+// RequestID returns the id the logging hooks assigned to the operation running
+// with ctx, or "". Use it to correlate your own logs with the client's.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// New returns hooks that log each operation to log: successes at info level,
+// client errors at warn and other failures at error. Retried attempts are
+// logged at debug level.
+func New(log logger.Logger, cfg Config) observe.Hooks {
+	if cfg.Rules == nil {
+		cfg.Rules = DefaultRules
+	}
+	if cfg.SensitiveKeys == nil {
+		cfg.SensitiveKeys = DefaultSensitiveKeys
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.MaxString <= 0 {
+		cfg.MaxString = DefaultMaxString
+	}
+	if cfg.MaxArray <= 0 {
+		cfg.MaxArray = DefaultMaxArray
+	}
+	f := newFormatter(cfg)
+
+	return observe.Hooks{
+		OnStart: func(ctx context.Context, op observe.Operation) context.Context {
+			return context.WithValue(ctx, requestIDKey{}, newRequestID())
+		},
+		OnAttempt: func(ctx context.Context, op observe.Operation, a observe.Attempt) {
+			if a.StatusCode < 400 && a.Err == nil {
+				return
+			}
+			fields := []zap.Field{
+				zap.String("request_id", RequestID(ctx)),
+				zap.String("operation", op.Name),
+				zap.String("model", op.Model),
+				zap.Int("attempt", a.Number),
+				zap.Int("status", a.StatusCode),
+				zap.Duration("latency", a.Duration),
+			}
+			if a.Err != nil {
+				fields = append(fields, zap.String("error", f.redactString(a.Err.Error())))
+			}
+			log.Debug("openrouter attempt failed", fields...)
+		},
+		OnEnd: func(ctx context.Context, op observe.Operation, res observe.Result) {
+			if res.Err == nil && cfg.SampleRate > 0 && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+				return
+			}
+			fields := []zap.Field{
+				zap.String("request_id", RequestID(ctx)),
+				zap.String("operation", op.Name),
+				zap.String("endpoint", op.Endpoint),
+				zap.String("model", op.Model),
+				zap.Int("status", res.StatusCode),
+				zap.Duration("latency", res.Duration),
+				zap.Int("attempts", res.Attempts),
+			}
+			if res.Model != "" && res.Model != op.Model {
+				fields = append(fields, zap.String("response_model", res.Model))
+			}
+			if res.ID != "" {
+				fields = append(fields, zap.String("generation_id", res.ID))
+			}
+			if res.Provider != "" {
+				fields = append(fields, zap.String("provider", res.Provider))
+			}
+			if res.InputTokens != 0 || res.OutputTokens != 0 {
+				fields = append(fields, zap.Int("input_tokens", res.InputTokens), zap.Int("output_tokens", res.OutputTokens))
+			}
+			if res.Cost != 0 {
+				fields = append(fields, zap.Float64("cost", res.Cost))
+			}
+			if res.TimeToFirstToken != 0 {
+				fields = append(fields, zap.Duration("time_to_first_token", res.TimeToFirstToken))
+			}
+			if len(res.FinishReasons) > 0 {
+				fields = append(fields, zap.Strings("finish_reasons", res.FinishReasons))
+			}
+			if cfg.Body != BodyNone {
+				if op.Request != nil {
+					fields = append(fields, zap.String("request_body", f.body(op.Request)))
+				}
+				if res.Response != nil {
+					fields = append(fields, zap.String("response_body", f.body(res.Response)))
+				}
+			}
+
+			switch {
+			case res.Err == nil:
+				log.Info("openrouter request", fields...)
+			case res.StatusCode >= 400 && res.StatusCode < 500:
+				log.Warn("openrouter request failed", append(fields, errorFields(f, res.Err)...)...)
+			default:
+				log.Error("openrouter request failed", append(fields, errorFields(f, res.Err)...)...)
+			}
+		},
+	}
+}
+
+func errorFields(f *formatter, err error) []zap.Field {
+	return []zap.Field{
+		zap.String("error", f.redactString(err.Error())),
+		zap.String("error_type", observe.ErrorType(err)),
+	}
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = cryptorand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rule replaces matches of Pattern in logged strings with Replacement, which
+// may refer to submatches as in regexp.Regexp.ReplaceAllString.
+type Rule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Secret rules.
+var (
+	RuleAPIKey = Rule{"api_key", regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{8,}`), "[REDACTED_KEY]"}
+	RuleBearer = Rule{"bearer", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/\-]+=*`), "Bearer [REDACTED]"}
+	RuleJWT    = Rule{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`), "[REDACTED_JWT]"}
+)
+
+// PII rules.
+var (
+	RuleEmail      = Rule{"email", regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "[EMAIL]"}
+	RuleCreditCard = Rule{"credit_card", regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), "[CARD]"}
+	RulePhone      = Rule{"phone", regexp.MustCompile(`\+?\d{1,3}[ .\-]?\(?\d{2,4}\)?[ .\-]?\d{3,4}[ .\-]?\d{3,4}\b`), "[PHONE]"}
+)
+
+// DefaultRules redact API keys, bearer tokens, JWTs, email addresses and card
+// numbers. RulePhone is not included, as it also matches many other numbers.
+var DefaultRules = []Rule{RuleAPIKey, RuleBearer, RuleJWT, RuleEmail, RuleCreditCard}
+
+// DefaultSensitiveKeys are JSON keys whose values are never logged.
+var DefaultSensitiveKeys = []string{"api_key", "apikey", "authorization", "password", "secret", "token", "access_token", "refresh_token"}
+
+// formatter renders bodies for logging.
+type formatter struct {
+	cfg       Config
+	sensitive map[string]bool
+}
+
+func newFormatter(cfg Config) *formatter {
+	f := &formatter{cfg: cfg, sensitive: make(map[string]bool, len(cfg.SensitiveKeys))}
+	for _, k := range cfg.SensitiveKeys {
+		f.sensitive[strings.ToLower(k)] = true
+	}
+	return f
+}
+
+// body renders v as redacted, and at BodyTruncated shortened, JSON.
+func (f *formatter) body(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "[unloggable: " + err.Error() + "]"
+	}
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return f.redactString(string(data))
+	}
+	data, _ = json.Marshal(f.walk(tree))
+	if f.cfg.Body == BodyTruncated && len(data) > f.cfg.MaxBodyBytes {
+		return string(data[:f.cfg.MaxBodyBytes]) + "...(+" + strconv.Itoa(len(data)-f.cfg.MaxBodyBytes) + " bytes)"
+	}
+	return string(data)
+}
+
+func (f *formatter) walk(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if f.sensitive[strings.ToLower(k)] {
+				v[k] = "[REDACTED]"
+				continue
+			}
+			v[k] = f.walk(val)
+		}
+		return v
+	case []any:
+		if f.cfg.Body == BodyTruncated && len(v) > f.cfg.MaxArray {
+			out := make([]any, f.cfg.MaxArray+1)
+			for i := range f.cfg.MaxArray {
+				out[i] = f.walk(v[i])
+			}
+			out[f.cfg.MaxArray] = "...(+" + strconv.Itoa(len(v)-f.cfg.MaxArray) + " items)"
+			return out
+		}
+		for i := range v {
+			v[i] = f.walk(v[i])
+		}
+		return v
+	case string:
+		return f.str(v)
+	}
+	return v
+}
+
+// str redacts s and, at BodyTruncated, elides base64 and shortens it.
+func (f *formatter) str(s string) string {
+	if f.cfg.Body == BodyTruncated {
+		if n, ok := base64Payload(s); ok && len(s) > 64 {
+			return "[base64 " + strconv.Itoa(n) + " bytes]"
+		}
+	}
+	s = f.redactString(s)
+	if f.cfg.Body == BodyTruncated && len(s) > f.cfg.MaxString {
+		s = s[:f.cfg.MaxString] + "...(+" + strconv.Itoa(len(s)-f.cfg.MaxString) + " bytes)"
+	}
+	return s
+}
+
+func (f *formatter) redactString(s string) string {
+	for _, r := range f.cfg.Rules {
+		s = r.Pattern.ReplaceAllString(s, r.Replacement)
+	}
+	return s
+}
+
+// base64Payload reports whether s is a base64 data URL or a long run of base64
+// characters, and the decoded size.
+func base64Payload(s string) (int, bool) {
+	if strings.HasPrefix(s, "data:") {
+		if i := strings.Index(s, ";base64,"); i >= 0 {
+			return len(s[i+8:]) * 3 / 4, true
+		}
+	}
+	if len(s) < 256 {
+		return 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/' || c == '=' || c == '-' || c == '_' || c == '\n') {
+			return 0, false
+		}
+	}
+	return len(s) * 3 / 4, true
+}
@@ -0,0 +1,101 @@
+// Package metrics records request, error, retry, latency, token and cost
+// metrics for client operations through a small backend interface. The
+// metrics/prometheus module adapts it to Prometheus.
+//
+//	client, _ := openrouter.NewClient(
+//	    openrouter.WithHooks(metrics.New(prometheus.New(prom.DefaultRegisterer))),
+//	)
+//
+// Series are labeled by endpoint (the client operation: "chat",
+// "chat.stream", "embeddings" or "models.list") and requested model.
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/observe"
+)
+
+// Metric names.
+const (
+	Requests         = "openrouter_requests_total"
+	Errors           = "openrouter_errors_total"
+	Retries          = "openrouter_retries_total"
+	RequestDuration  = "openrouter_request_duration_seconds"
+	TimeToFirstToken = "openrouter_time_to_first_token_seconds"
+	Tokens           = "openrouter_tokens_total"
+	Cost             = "openrouter_cost_total"
+)
+
+// Default histogram buckets, in seconds.
+var (
+	DurationBuckets         = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80, 160}
+	TimeToFirstTokenBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 32}
+)
+
+// Counter is a monotonically increasing metric. Label values are given in
+// the order of the label names it was created with.
+type Counter interface {
+	Add(v float64, labelValues ...string)
+}
+
+// Histogram records observations in buckets.
+type Histogram interface {
+	Observe(v float64, labelValues ...string)
+}
+
+// Provider creates metrics in a backend.
+type Provider interface {
+	Counter(name, help string, labelNames ...string) Counter
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// New returns hooks that record the following metrics in p:
+//
+//   - openrouter_requests_total{endpoint, model, status}: finished operations;
+//     status is the last HTTP status, or 0 if none was received
+//   - openrouter_errors_total{endpoint, model, code}: failed operations by
+//     observe.ErrorType, e.g. the OpenRouter error code
+//   - openrouter_retries_total{endpoint, model}: HTTP attempts after the first
+//   - openrouter_request_duration_seconds{endpoint, model}: operation latency;
+//     for streams, until the stream ended
+//   - openrouter_time_to_first_token_seconds{model}: stream time to first token
+//   - openrouter_tokens_total{endpoint, model, direction}: reported input and
+//     output tokens
+//   - openrouter_cost_total{endpoint, model}: reported cost in credits
+func New(p Provider) observe.Hooks {
+	requests := p.Counter(Requests, "Client operations by final HTTP status.", "endpoint", "model", "status")
+	errs := p.Counter(Errors, "Failed client operations by error code.", "endpoint", "model", "code")
+	retries := p.Counter(Retries, "HTTP attempts retried after the first.", "endpoint", "model")
+	duration := p.Histogram(RequestDuration, "Client operation latency in seconds.", DurationBuckets, "endpoint", "model")
+	ttft := p.Histogram(TimeToFirstToken, "Stream time to first token in seconds.", TimeToFirstTokenBuckets, "model")
+	tokens := p.Counter(Tokens, "Reported tokens by direction (input or output).", "endpoint", "model", "direction")
+	cost := p.Counter(Cost, "Reported cost in credits.", "endpoint", "model")
+
+	return observe.Hooks{
+		OnFirstToken: func(ctx context.Context, op observe.Operation, d time.Duration) {
+			ttft.Observe(d.Seconds(), op.Model)
+		},
+		OnEnd: func(ctx context.Context, op observe.Operation, res observe.Result) {
+			requests.Add(1, op.Name, op.Model, strconv.Itoa(res.StatusCode))
+			duration.Observe(res.Duration.Seconds(), op.Name, op.Model)
+			if res.Err != nil {
+				errs.Add(1, op.Name, op.Model, observe.ErrorType(res.Err))
+			}
+			if res.Attempts > 1 {
+				retries.Add(float64(res.Attempts-1), op.Name, op.Model)
+			}
+			if res.InputTokens > 0 {
+				tokens.Add(float64(res.InputTokens), op.Name, op.Model, "input")
+			}
+			if res.OutputTokens > 0 {
+				tokens.Add(float64(res.OutputTokens), op.Name, op.Model, "output")
+			}
+			if res.Cost > 0 {
+				cost.Add(res.Cost, op.Name, op.Model)
+			}
+		},
+	}
+}
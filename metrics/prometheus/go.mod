@@ -0,0 +1,24 @@
+module github.com/MetaDiv-AI/openrouter/metrics/prometheus
+
+go 1.23.2
+
+require (
+	github.com/MetaDiv-AI/openrouter v1.3.0
+	github.com/prometheus/client_golang v1.20.5
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+// The client is developed alongside this module. The replace is ignored when
+// this module is a dependency, so the root must be tagged v1.3.0, the release
+// that adds metrics, before this module is tagged metrics/prometheus/v*.
+replace github.com/MetaDiv-AI/openrouter => ../../
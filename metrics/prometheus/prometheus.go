@@ -0,0 +1,60 @@
+// Package prometheus adapts metrics.Provider to a Prometheus registry.
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/MetaDiv-AI/openrouter/metrics"
+)
+
+// Provider creates Prometheus counters and histograms.
+type Provider struct {
+	reg prom.Registerer
+}
+
+var _ metrics.Provider = (*Provider)(nil)
+
+// New returns a Provider registering its metrics with reg, e.g.
+// prometheus.DefaultRegisterer. Metrics already registered (by another client
+// using the same registry) are reused.
+func New(reg prom.Registerer) *Provider {
+	return &Provider{reg: reg}
+}
+
+// Counter implements metrics.Provider.
+func (p *Provider) Counter(name, help string, labelNames ...string) metrics.Counter {
+	vec := prom.NewCounterVec(prom.CounterOpts{Name: name, Help: help}, labelNames)
+	return counter{register(p.reg, vec)}
+}
+
+// Histogram implements metrics.Provider.
+func (p *Provider) Histogram(name, help string, buckets []float64, labelNames ...string) metrics.Histogram {
+	vec := prom.NewHistogramVec(prom.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labelNames)
+	return histogram{register(p.reg, vec)}
+}
+
+// register registers c, returning the existing collector if an identical one
+// is already registered.
+func register[C prom.Collector](reg prom.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prom.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+type counter struct{ vec *prom.CounterVec }
+
+func (c counter) Add(v float64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(v)
+}
+
+type histogram struct{ vec *prom.HistogramVec }
+
+func (h histogram) Observe(v float64, labelValues ...string) {
+	h.vec.WithLabelValues(labelValues...).Observe(v)
+}
@@ -6,11 +6,20 @@ import (
 	"time"
 
 	"github.com/MetaDiv-AI/openrouter/internal"
+	"github.com/MetaDiv-AI/openrouter/observe"
 )
 
 // DefaultListCacheTTL is the default duration to cache the models list.
 const DefaultListCacheTTL = 5 * time.Minute
 
+// Catalog looks up the available models. *Service implements it.
+type Catalog interface {
+	List(ctx context.Context) ([]Model, error)
+	Get(ctx context.Context, id string) (*Model, error)
+}
+
+var _ Catalog = (*Service)(nil)
+
 // Service provides model listing and discovery.
 type Service struct {
 	caller      *internal.Caller
@@ -68,8 +77,16 @@ type TopProvider struct {
 	IsModerated         bool `json:"is_moderated"`
 }
 
-// List returns all available models. Results are cached for DefaultListCacheTTL.
+// List returns all available models. Results are cached for DefaultListCacheTTL;
+// a cached result is observed as an operation with no attempts.
 func (s *Service) List(ctx context.Context) ([]Model, error) {
+	ctx, op := s.caller.StartOp(ctx, observe.Operation{Name: observe.OpModelsList, Endpoint: "/models"})
+	list, err := s.list(ctx)
+	op.End(observe.Result{Err: err, Response: list})
+	return list, err
+}
+
+func (s *Service) list(ctx context.Context) ([]Model, error) {
 	s.cacheMu.RLock()
 	if time.Now().Before(s.cacheExpiry) && len(s.cache) > 0 {
 		list := s.cache
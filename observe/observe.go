@@ -0,0 +1,132 @@
+// Package observe defines the hooks through which tracing, metrics and logging
+// integrations watch client operations. Register them with
+// openrouter.WithHooks.
+//
+// An operation is one client call (Chat.Create, Chat.CreateStream,
+// Embeddings.Create or Models.List). It makes zero or more HTTP attempts:
+// retries, and the extra requests of a stream fallback.
+package observe
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// Operation names.
+const (
+	OpChat       = "chat"
+	OpChatStream = "chat.stream"
+	OpEmbeddings = "embeddings"
+	OpModelsList = "models.list"
+)
+
+// Operation describes a client call.
+type Operation struct {
+	// Name is one of the Op constants.
+	Name string
+	// Endpoint is the API path, e.g. "/chat/completions".
+	Endpoint string
+	// Model is the requested model, if any.
+	Model string
+	// Request is the request value (*chat.ChatRequest or
+	// *embeddings.CreateRequest), or nil. Hooks must not modify it.
+	Request any
+	// Start is when the operation began.
+	Start time.Time
+}
+
+// Attempt describes one HTTP round trip of an operation.
+type Attempt struct {
+	// Number counts the operation's attempts from 1.
+	Number int
+	// Request is the outgoing request. Its body has been sent.
+	Request *http.Request
+	// StatusCode is the response status, or 0 if no response was received.
+	StatusCode int
+	// Header is the response header, or nil.
+	Header http.Header
+	// Duration is the time until the response headers arrived.
+	Duration time.Duration
+	// Err is the transport error, if any. API errors have a StatusCode instead.
+	Err error
+}
+
+// Result describes how an operation ended.
+type Result struct {
+	// Model is the model that responded, which may differ from the requested
+	// one (fallbacks, auto routing).
+	Model string
+	// Provider is the upstream provider that served the request.
+	Provider string
+	// ID is the generation ID.
+	ID string
+	// InputTokens and OutputTokens are the reported token usage.
+	InputTokens  int
+	OutputTokens int
+	// Cost is the reported cost in credits, when usage accounting is on.
+	Cost float64
+	// FinishReasons holds the finish reason of each choice.
+	FinishReasons []string
+	// Attempts is the number of HTTP attempts made.
+	Attempts int
+	// StatusCode is the status of the last attempt, or 0.
+	StatusCode int
+	// TimeToFirstToken is the wait for the first content chunk of a stream.
+	TimeToFirstToken time.Duration
+	// Duration is the operation's total duration; for streams, until the
+	// stream ended.
+	Duration time.Duration
+	// Err is the error the operation ended with, or nil.
+	Err error
+	// Response is the decoded response (*chat.ChatResponse,
+	// *embeddings.CreateResponse or []models.Model), or nil for streams and
+	// failures. Hooks must not modify it.
+	Response any
+}
+
+// Hooks are callbacks invoked during operations. Nil fields are skipped. When
+// several Hooks are registered they are called in registration order, and
+// OnEnd in reverse order.
+type Hooks struct {
+	// OnStart is called when an operation begins. The returned context, which
+	// must derive from ctx, is used for the operation and passed to the other
+	// hooks, so it can carry a span or timer.
+	OnStart func(ctx context.Context, op Operation) context.Context
+	// OnRequest is called before each HTTP attempt, including requests made
+	// outside an operation. It may add headers, e.g. for trace propagation.
+	OnRequest func(ctx context.Context, req *http.Request)
+	// OnAttempt is called after each HTTP attempt of an operation.
+	OnAttempt func(ctx context.Context, op Operation, a Attempt)
+	// OnFirstToken is called when a stream delivers its first content chunk.
+	OnFirstToken func(ctx context.Context, op Operation, ttft time.Duration)
+	// OnEnd is called once when the operation ends.
+	OnEnd func(ctx context.Context, op Operation, res Result)
+}
+
+// ErrorType classifies err for use as a low-cardinality label or the
+// error.type attribute: the OpenRouter error code (e.g. "429"), the stream
+// timeout kind (e.g. "idle_timeout"), "circuit_open", "canceled", "timeout",
+// or "_OTHER".
+func ErrorType(err error) string {
+	var oerr *errors.OpenRouterError
+	var terr *errors.StreamTimeoutError
+	switch {
+	case stderrors.As(err, &oerr):
+		return strconv.Itoa(oerr.Code)
+	case stderrors.As(err, &terr):
+		return strings.ReplaceAll(string(terr.Kind), " ", "_") + "_timeout"
+	case stderrors.Is(err, errors.ErrCircuitOpen):
+		return "circuit_open"
+	case stderrors.Is(err, context.Canceled):
+		return "canceled"
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "_OTHER"
+}
@@ -0,0 +1,247 @@
+package openroutertest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/embeddings"
+)
+
+// Reply scripts one response of the fake server. Build replies with Text,
+// ToolCalls, Error, RateLimited, MidStreamError, Embedding or JSON and adjust
+// them with the With methods.
+type Reply struct {
+	// Status is the HTTP status code of non-streamed responses; 200 if zero.
+	// Streamed events are always sent with 200.
+	Status int
+	// Header is added to the response headers.
+	Header http.Header
+	// Body is JSON-encoded as the response to non-streaming requests, or to
+	// every request when Events is empty. A []byte or string body is written
+	// as is.
+	Body any
+	// Events are written as server-sent events when the request sets
+	// "stream": true, followed by "data: [DONE]" unless the last event is an
+	// error chunk.
+	Events []Event
+	// Delay is waited before the response headers are written.
+	Delay time.Duration
+
+	expect func(Request) error
+}
+
+// Event is one server-sent event of a streamed reply.
+type Event struct {
+	// Delay is waited before the event is written.
+	Delay time.Duration
+	// Chunk is written as a "data:" event. Empty ID and Model fields are
+	// filled in from the request.
+	Chunk *chat.StreamChunk
+	// Raw, if set, is written verbatim instead of Chunk (for comments or
+	// malformed data). Include the terminating blank line.
+	Raw string
+}
+
+// Text replies with an assistant message. Streamed, the content is sent one
+// word per chunk, then a finish chunk and a usage-only chunk. Usage counts
+// one completion token per word.
+func Text(content string) Reply {
+	words := strings.SplitAfter(content, " ")
+	usage := &chat.Usage{CompletionTokens: len(words), TotalTokens: len(words)}
+
+	events := make([]Event, 0, len(words)+2)
+	for _, w := range words {
+		events = append(events, deltaEvent(&chat.Message{Role: "assistant", Content: w}))
+	}
+	events = append(events, finishEvent("stop"), usageEvent(usage))
+
+	return Reply{
+		Body: &chat.ChatResponse{
+			Object: "chat.completion",
+			Choices: []chat.Choice{{
+				Message:      &chat.Message{Role: "assistant", Content: content},
+				FinishReason: "stop",
+			}},
+			Usage: usage,
+		},
+		Events: events,
+	}
+}
+
+// ToolCalls replies with an assistant message calling the given tools. IDs
+// default to "call_<n>" and types to "function". Streamed, each call's
+// arguments are split across two chunks, as providers do.
+func ToolCalls(calls ...chat.ToolCall) Reply {
+	calls = append([]chat.ToolCall(nil), calls...)
+	for i := range calls {
+		if calls[i].ID == "" {
+			calls[i].ID = "call_" + strconv.Itoa(i)
+		}
+		if calls[i].Type == "" {
+			calls[i].Type = "function"
+		}
+	}
+	usage := &chat.Usage{CompletionTokens: len(calls), TotalTokens: len(calls)}
+
+	var events []Event
+	for i, tc := range calls {
+		args := tc.Function.Arguments
+		head, tail := args[:len(args)/2], args[len(args)/2:]
+		first := tc
+		first.Index = intPtr(i)
+		first.Function.Arguments = head
+		events = append(events, deltaEvent(&chat.Message{Role: "assistant", ToolCalls: []chat.ToolCall{first}}))
+		if tail != "" {
+			rest := chat.ToolCall{Index: intPtr(i), Function: chat.FunctionCall{Arguments: tail}}
+			events = append(events, deltaEvent(&chat.Message{ToolCalls: []chat.ToolCall{rest}}))
+		}
+	}
+	events = append(events, finishEvent("tool_calls"), usageEvent(usage))
+
+	return Reply{
+		Body: &chat.ChatResponse{
+			Object: "chat.completion",
+			Choices: []chat.Choice{{
+				Message:      &chat.Message{Role: "assistant", ToolCalls: calls},
+				FinishReason: "tool_calls",
+			}},
+			Usage: usage,
+		},
+		Events: events,
+	}
+}
+
+// ToolCall is a shorthand for one function call with JSON arguments.
+func ToolCall(name, arguments string) chat.ToolCall {
+	return chat.ToolCall{Function: chat.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+// Error replies with an OpenRouter error body and the given HTTP status.
+func Error(status int, message string) Reply {
+	return Reply{Status: status, Body: errorBody(status, message)}
+}
+
+// RateLimited replies 429 with a Retry-After header of retryAfter, rounded up
+// to whole seconds.
+func RateLimited(retryAfter time.Duration) Reply {
+	r := Error(http.StatusTooManyRequests, "rate limit exceeded")
+	secs := int((retryAfter + time.Second - 1) / time.Second)
+	return r.WithHeader("Retry-After", strconv.Itoa(secs))
+}
+
+// MidStreamError streams content like Text, then an error chunk with code
+// and message instead of the finish and usage chunks, as OpenRouter does when
+// a provider fails after the response has started. Non-streaming requests get
+// an error response with status code.
+func MidStreamError(content string, code int, message string) Reply {
+	r := Text(content)
+	r.Events = r.Events[:len(r.Events)-2]
+	r.Events = append(r.Events, Event{Chunk: &chat.StreamChunk{
+		Object: "chat.completion.chunk",
+		Error:  &chat.StreamError{Code: code, Message: message},
+	}})
+	r.Body = errorBody(code, message)
+	r.Status = code
+	return r
+}
+
+// Embedding replies with one embedding per vector, base64-encoded if the
+// request asks for embeddings.EncodingBase64.
+func Embedding(vectors ...[]float32) Reply {
+	data := make([]embeddings.EmbeddingData, len(vectors))
+	for i, v := range vectors {
+		data[i] = embeddings.EmbeddingData{Object: "embedding", Embedding: v, Index: i}
+	}
+	return Reply{Body: &embeddings.CreateResponse{Data: data, Usage: &embeddings.Usage{}}}
+}
+
+// JSON replies with status and body encoded as JSON.
+func JSON(status int, body any) Reply {
+	return Reply{Status: status, Body: body}
+}
+
+// WithDelay returns a copy of r that waits d before responding.
+func (r Reply) WithDelay(d time.Duration) Reply {
+	r.Delay = d
+	return r
+}
+
+// WithChunkDelay returns a copy of r that waits d before each streamed event.
+func (r Reply) WithChunkDelay(d time.Duration) Reply {
+	events := make([]Event, len(r.Events))
+	for i, ev := range r.Events {
+		ev.Delay = d
+		events[i] = ev
+	}
+	r.Events = events
+	return r
+}
+
+// WithHeader returns a copy of r with a response header added.
+func (r Reply) WithHeader(key, value string) Reply {
+	h := r.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Add(key, value)
+	r.Header = h
+	return r
+}
+
+// WithUsage returns a copy of r reporting usage instead of the default.
+func (r Reply) WithUsage(usage chat.Usage) Reply {
+	if resp, ok := r.Body.(*chat.ChatResponse); ok {
+		c := *resp
+		c.Usage = &usage
+		r.Body = &c
+	}
+	events := make([]Event, len(r.Events))
+	for i, ev := range r.Events {
+		if ev.Chunk != nil && ev.Chunk.Usage != nil {
+			c := *ev.Chunk
+			c.Usage = &usage
+			ev.Chunk = &c
+		}
+		events[i] = ev
+	}
+	r.Events = events
+	return r
+}
+
+// Expect returns a copy of r that checks the request it answers. A non-nil
+// error from check fails the test.
+func (r Reply) Expect(check func(Request) error) Reply {
+	r.expect = check
+	return r
+}
+
+func deltaEvent(delta *chat.Message) Event {
+	return Event{Chunk: &chat.StreamChunk{
+		Object:  "chat.completion.chunk",
+		Choices: []chat.Choice{{Delta: delta}},
+	}}
+}
+
+func finishEvent(reason string) Event {
+	return Event{Chunk: &chat.StreamChunk{
+		Object:  "chat.completion.chunk",
+		Choices: []chat.Choice{{Delta: &chat.Message{}, FinishReason: reason}},
+	}}
+}
+
+func usageEvent(usage *chat.Usage) Event {
+	return Event{Chunk: &chat.StreamChunk{
+		Object:  "chat.completion.chunk",
+		Choices: []chat.Choice{},
+		Usage:   usage,
+	}}
+}
+
+func errorBody(code int, message string) map[string]any {
+	return map[string]any{"error": map[string]any{"code": code, "message": message}}
+}
+
+func intPtr(i int) *int { return &i }
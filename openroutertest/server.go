@@ -0,0 +1,337 @@
+// Package openroutertest provides an in-process fake OpenRouter server for
+// testing code that uses *openrouter.Client.
+//
+//	srv := openroutertest.NewServer(t)
+//	srv.Chat(
+//	    openroutertest.RateLimited(time.Second),
+//	    openroutertest.Text("Hello!").WithChunkDelay(10*time.Millisecond),
+//	)
+//	client := srv.Client()
+//	resp, err := client.Chat.Create(ctx, req)
+//	...
+//	srv.AssertRequests(t, "/chat/completions", 2)
+//
+// Replies are queued per endpoint and served in order; a request with no
+// reply left fails the test with a 500 response.
+package openroutertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter"
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/embeddings"
+	"github.com/MetaDiv-AI/openrouter/models"
+	"github.com/MetaDiv-AI/openrouter/retry"
+)
+
+// API paths served by the fake server.
+const (
+	ChatPath       = "/chat/completions"
+	EmbeddingsPath = "/embeddings"
+	ModelsPath     = "/models"
+)
+
+// APIKey is the key Client configures.
+const APIKey = "sk-or-test"
+
+// Server is a fake OpenRouter API. Its URL is the base URL to pass to
+// openrouter.WithBaseURL.
+type Server struct {
+	*httptest.Server
+	tb testing.TB
+
+	mu       sync.Mutex
+	replies  map[string][]Reply
+	models   []models.Model
+	requests []Request
+	seq      int
+}
+
+// Request is a request received by the fake server.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// APIKey returns the bearer token the request was sent with.
+func (r Request) APIKey() string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// ChatRequest decodes the body as a chat completion request.
+func (r Request) ChatRequest() (*chat.ChatRequest, error) {
+	var req chat.ChatRequest
+	if err := json.Unmarshal(r.Body, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// EmbeddingsRequest decodes the body as an embeddings request.
+func (r Request) EmbeddingsRequest() (*embeddings.CreateRequest, error) {
+	var req embeddings.CreateRequest
+	if err := json.Unmarshal(r.Body, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// NewServer starts a fake server that is closed when the test ends.
+// Unexpected requests and failed expectations are reported to tb.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{tb: tb, replies: make(map[string][]Reply)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a client for the server with APIKey and a retry backoff of a
+// few milliseconds. opts are applied after these defaults.
+func (s *Server) Client(opts ...openrouter.Option) *openrouter.Client {
+	s.tb.Helper()
+	policy := retry.DefaultPolicy()
+	policy.Backoff = retry.Backoff{InitialInterval: time.Millisecond, MaxInterval: 10 * time.Millisecond, Multiplier: 2}
+	base := []openrouter.Option{
+		openrouter.WithAPIKey(APIKey),
+		openrouter.WithBaseURL(s.URL),
+		openrouter.WithRetryPolicy(policy),
+	}
+	client, err := openrouter.NewClient(append(base, opts...)...)
+	if err != nil {
+		s.tb.Fatalf("openroutertest: %v", err)
+	}
+	return client
+}
+
+// Chat queues replies for chat completion requests.
+func (s *Server) Chat(replies ...Reply) {
+	s.Handle(ChatPath, replies...)
+}
+
+// Embeddings queues replies for embeddings requests.
+func (s *Server) Embeddings(replies ...Reply) {
+	s.Handle(EmbeddingsPath, replies...)
+}
+
+// Models sets the list served for every models request. Without it the list
+// is empty.
+func (s *Server) Models(list ...models.Model) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.models = list
+}
+
+// Handle queues replies for requests to path.
+func (s *Server) Handle(path string, replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[path] = append(s.replies[path], replies...)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ChatRequests returns the decoded chat completion requests received so far.
+func (s *Server) ChatRequests() []*chat.ChatRequest {
+	var out []*chat.ChatRequest
+	for _, r := range s.Requests() {
+		if r.Path != ChatPath {
+			continue
+		}
+		if req, err := r.ChatRequest(); err == nil {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+// AssertRequests fails the test unless exactly n requests were sent to path.
+func (s *Server) AssertRequests(tb testing.TB, path string, n int) {
+	tb.Helper()
+	got := 0
+	for _, r := range s.Requests() {
+		if r.Path == path {
+			got++
+		}
+	}
+	if got != n {
+		tb.Errorf("openroutertest: got %d requests to %s, want %d", got, path, n)
+	}
+}
+
+// AssertExhausted fails the test if queued replies were not served.
+func (s *Server) AssertExhausted(tb testing.TB) {
+	tb.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for path, queue := range s.replies {
+		if len(queue) > 0 {
+			tb.Errorf("openroutertest: %d unused replies for %s", len(queue), path)
+		}
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.seq++
+	id := fmt.Sprintf("gen-test-%d", s.seq)
+	if r.Method == http.MethodGet && r.URL.Path == ModelsPath {
+		list := models.ListResponse{Data: append([]models.Model{}, s.models...)}
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+	queue := s.replies[r.URL.Path]
+	if len(queue) == 0 {
+		s.mu.Unlock()
+		s.tb.Errorf("openroutertest: unexpected %s %s", r.Method, r.URL.Path)
+		writeJSON(w, http.StatusInternalServerError, errorBody(500, "openroutertest: no reply queued for "+r.URL.Path))
+		return
+	}
+	reply := queue[0]
+	s.replies[r.URL.Path] = queue[1:]
+	s.mu.Unlock()
+
+	if reply.expect != nil {
+		if err := reply.expect(req); err != nil {
+			s.tb.Errorf("openroutertest: %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	}
+	if !sleep(r.Context(), reply.Delay) {
+		return
+	}
+	for k, vs := range reply.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+
+	var meta struct {
+		Model          string `json:"model"`
+		Stream         bool   `json:"stream"`
+		EncodingFormat string `json:"encoding_format"`
+	}
+	_ = json.Unmarshal(body, &meta)
+	if meta.Stream && len(reply.Events) > 0 {
+		s.stream(w, r, reply.Events, id, meta.Model)
+		return
+	}
+	status := reply.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if resp, ok := reply.Body.(*chat.ChatResponse); ok {
+		c := *resp
+		fill(&c.ID, id)
+		fill(&c.Model, meta.Model)
+		reply.Body = &c
+	}
+	if resp, ok := reply.Body.(*embeddings.CreateResponse); ok && meta.EncodingFormat == embeddings.EncodingBase64 {
+		reply.Body = base64Embeddings(resp)
+	}
+	writeJSON(w, status, reply.Body)
+}
+
+// base64Embeddings returns resp with its vectors in the base64 wire format.
+func base64Embeddings(resp *embeddings.CreateResponse) any {
+	type data struct {
+		Object    string `json:"object"`
+		Embedding string `json:"embedding"`
+		Index     int    `json:"index"`
+	}
+	out := struct {
+		Data  []data            `json:"data"`
+		Usage *embeddings.Usage `json:"usage,omitempty"`
+	}{Data: make([]data, len(resp.Data)), Usage: resp.Usage}
+	for i, d := range resp.Data {
+		out.Data[i] = data{Object: d.Object, Embedding: d.Embedding.Base64(), Index: d.Index}
+	}
+	return out
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, events []Event, id, model string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	_ = rc.Flush()
+
+	for _, ev := range events {
+		if !sleep(r.Context(), ev.Delay) {
+			return
+		}
+		frame := ev.Raw
+		if frame == "" && ev.Chunk != nil {
+			c := *ev.Chunk
+			fill(&c.ID, id)
+			fill(&c.Model, model)
+			data, _ := json.Marshal(&c)
+			frame = "data: " + string(data) + "\n\n"
+		}
+		if _, err := io.WriteString(w, frame); err != nil {
+			return
+		}
+		_ = rc.Flush()
+	}
+	if last := events[len(events)-1]; last.Chunk == nil || last.Chunk.Error == nil {
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+		_ = rc.Flush()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	var data []byte
+	switch b := body.(type) {
+	case []byte:
+		data = b
+	case string:
+		data = []byte(b)
+	default:
+		data, _ = json.Marshal(body)
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// sleep waits d and reports whether ctx is still live.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func fill(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is a token bucket that lends against future refills: a reservation
+// always succeeds and reports how long the caller must wait for it.
+type bucket struct {
+	rate   float64 // tokens per second at full speed
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate, burst float64, now time.Time) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = math.Max(1, math.Ceil(rate))
+	}
+	return &bucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+func (b *bucket) reserve(now time.Time, n, factor float64) time.Duration {
+	if b == nil || n <= 0 {
+		return 0
+	}
+	rate := b.rate * factor
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+func (b *bucket) cancel(n float64) {
+	if b == nil || n <= 0 {
+		return
+	}
+	b.tokens = math.Min(b.burst, b.tokens+n)
+}
+
+// bucketPair limits requests and tokens together.
+type bucketPair struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+func newBucketPair(lim Limits, now time.Time) *bucketPair {
+	tpm := float64(lim.TokensPerMinute)
+	return &bucketPair{
+		requests: newBucket(lim.RequestsPerSecond, float64(lim.Burst), now),
+		tokens:   newBucket(tpm/60, tpm, now),
+	}
+}
+
+func (p *bucketPair) reserve(now time.Time, tokens int, factor float64) time.Duration {
+	d := p.requests.reserve(now, 1, factor)
+	if t := p.tokens.reserve(now, float64(tokens), factor); t > d {
+		d = t
+	}
+	return d
+}
+
+func (p *bucketPair) cancel(tokens int) {
+	p.requests.cancel(1)
+	p.tokens.cancel(float64(tokens))
+}
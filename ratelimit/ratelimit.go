@@ -0,0 +1,163 @@
+// Package ratelimit provides a client-side token-bucket limiter shared by all
+// services of a client (chat, embeddings, models and batch runs).
+//
+// Requests are limited per second and, when a token estimate is available,
+// estimated tokens per minute. Limits can be set globally and per model. When
+// the API answers 429 the limiter slows down and recovers gradually.
+package ratelimit
+
+import (
+	"context"
+	stderrors "errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// Default adaptive behavior.
+const (
+	DefaultSlowdownFactor = 0.5
+	DefaultMinFactor      = 0.1
+	DefaultRecovery       = time.Minute
+)
+
+// Limits configures a pair of token buckets. Zero values disable the corresponding limit.
+type Limits struct {
+	RequestsPerSecond float64
+	// Burst is the number of requests allowed at once. Defaults to RequestsPerSecond rounded up.
+	Burst           int
+	TokensPerMinute int
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// Limits apply to every request.
+	Limits
+	// PerModel adds limits for individual model IDs on top of the global ones.
+	PerModel map[string]Limits
+	// SlowdownFactor multiplies the allowed rate each time a 429 is observed.
+	// Defaults to DefaultSlowdownFactor.
+	SlowdownFactor float64
+	// MinFactor bounds how far repeated 429s can slow the limiter down.
+	// Defaults to DefaultMinFactor.
+	MinFactor float64
+	// Recovery is how long the rate takes to climb back to the configured
+	// limits after the last 429. Defaults to DefaultRecovery.
+	Recovery time.Duration
+}
+
+// Limiter is a token-bucket rate limiter. It is safe for concurrent use and
+// may be shared between clients.
+type Limiter struct {
+	cfg Config
+
+	mu        sync.Mutex
+	global    *bucketPair
+	models    map[string]*bucketPair
+	factor    float64   // rate multiplier right after the last 429
+	throttled time.Time // time of the last 429
+	pausedTil time.Time // honors Retry-After on 429
+}
+
+// New creates a Limiter from cfg.
+func New(cfg Config) *Limiter {
+	if cfg.SlowdownFactor <= 0 || cfg.SlowdownFactor >= 1 {
+		cfg.SlowdownFactor = DefaultSlowdownFactor
+	}
+	if cfg.MinFactor <= 0 || cfg.MinFactor > 1 {
+		cfg.MinFactor = DefaultMinFactor
+	}
+	if cfg.Recovery <= 0 {
+		cfg.Recovery = DefaultRecovery
+	}
+	now := time.Now()
+	l := &Limiter{
+		cfg:    cfg,
+		global: newBucketPair(cfg.Limits, now),
+		models: make(map[string]*bucketPair, len(cfg.PerModel)),
+		factor: 1,
+	}
+	for model, lim := range cfg.PerModel {
+		l.models[model] = newBucketPair(lim, now)
+	}
+	return l
+}
+
+// Wait blocks until a request for model with the given estimated token count
+// may proceed, or ctx is done. tokens may be 0 when no estimate is available.
+func (l *Limiter) Wait(ctx context.Context, model string, tokens int) error {
+	l.mu.Lock()
+	now := time.Now()
+	factor := l.currentFactor(now)
+	delay := l.global.reserve(now, tokens, factor)
+	mb := l.models[model]
+	if mb != nil {
+		if d := mb.reserve(now, tokens, factor); d > delay {
+			delay = d
+		}
+	}
+	if d := l.pausedTil.Sub(now); d > delay {
+		delay = d
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.global.cancel(tokens)
+		if mb != nil {
+			mb.cancel(tokens)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe records the outcome of a request. A 429 slows the limiter down and,
+// when the response carried Retry-After, pauses all requests until then.
+func (l *Limiter) Observe(err error) {
+	var oerr *errors.OpenRouterError
+	if !stderrors.As(err, &oerr) || oerr.Code != 429 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.factor = math.Max(l.currentFactor(now)*l.cfg.SlowdownFactor, l.cfg.MinFactor)
+	l.throttled = now
+	if oerr.RetryAfter > 0 {
+		if until := now.Add(oerr.RetryAfter); until.After(l.pausedTil) {
+			l.pausedTil = until
+		}
+	}
+}
+
+// Factor returns the fraction of the configured rate currently allowed
+// (1 when no 429 has been observed recently).
+func (l *Limiter) Factor() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.currentFactor(time.Now())
+}
+
+// currentFactor linearly recovers the slowdown factor to 1 over cfg.Recovery.
+func (l *Limiter) currentFactor(now time.Time) float64 {
+	if l.factor >= 1 {
+		return 1
+	}
+	progress := float64(now.Sub(l.throttled)) / float64(l.cfg.Recovery)
+	if progress >= 1 {
+		l.factor = 1
+		return 1
+	}
+	return l.factor + (1-l.factor)*progress
+}
@@ -0,0 +1,167 @@
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Detector finds one kind of sensitive value.
+type Detector struct {
+	// Name labels the tokens that replace matches, e.g. "email" gives
+	// "[EMAIL_1]".
+	Name string
+	// Pattern matches candidate values.
+	Pattern *regexp.Regexp
+	// Valid, if set, rejects false positives among the matches.
+	Valid func(match string) bool
+}
+
+// Built-in detectors.
+var (
+	Email      = Detector{Name: "email", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)}
+	CreditCard = Detector{Name: "credit_card", Pattern: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), Valid: luhn}
+	Phone      = Detector{Name: "phone", Pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .\-]?\d{3,4}[ .\-]?\d{3,4}\b`)}
+)
+
+// DefaultDetectors find email addresses, card numbers and phone numbers. Phone
+// also matches other runs of 7 to 12 digits, erring on the side of masking.
+var DefaultDetectors = []Detector{Email, CreditCard, Phone}
+
+// Pattern returns a detector for a custom regular expression. It panics if
+// expr does not compile.
+func Pattern(name, expr string) Detector {
+	return Detector{Name: name, Pattern: regexp.MustCompile(expr)}
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// tokenPattern matches the tokens a Vault issues.
+var tokenPattern = regexp.MustCompile(`\[[A-Z0-9_]+_\d+\]`)
+
+// Vault holds the tokens issued while redacting one request and the values
+// they replace. The same value always gets the same token.
+type Vault struct {
+	mu     sync.Mutex
+	values map[string]string // token -> value
+	tokens map[string]string // name + "\x00" + value -> token
+	counts map[string]int
+	maxLen int
+}
+
+func newVault() *Vault {
+	return &Vault{values: make(map[string]string), tokens: make(map[string]string), counts: make(map[string]int)}
+}
+
+// Len returns the number of distinct values redacted.
+func (v *Vault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.values)
+}
+
+// Restore replaces the tokens in s with the values they stand for. Unknown
+// tokens are left as they are.
+func (v *Vault) Restore(s string) string {
+	if !strings.Contains(s, "[") {
+		return s
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.values) == 0 {
+		return s
+	}
+	return tokenPattern.ReplaceAllStringFunc(s, func(tok string) string {
+		if val, ok := v.values[tok]; ok {
+			return val
+		}
+		return tok
+	})
+}
+
+// token returns the token for value found by detector name.
+func (v *Vault) token(name, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := name + "\x00" + value
+	if tok, ok := v.tokens[key]; ok {
+		return tok
+	}
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+	v.counts[label]++
+	tok := "[" + label + "_" + strconv.Itoa(v.counts[label]) + "]"
+	v.tokens[key] = tok
+	v.values[tok] = value
+	v.maxLen = max(v.maxLen, len(tok))
+	return tok
+}
+
+// tokenLen returns the length of the longest token issued.
+func (v *Vault) tokenLen() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.maxLen
+}
+
+// redact replaces the values detectors find in s with tokens. Where matches
+// overlap, the one starting first wins, then the earlier detector.
+func (v *Vault) redact(s string, detectors []Detector) string {
+	type match struct {
+		start, end int
+		name       string
+	}
+	var matches []match
+	for _, d := range detectors {
+		for _, m := range d.Pattern.FindAllStringIndex(s, -1) {
+			if d.Valid != nil && !d.Valid(s[m[0]:m[1]]) {
+				continue
+			}
+			matches = append(matches, match{m[0], m[1], d.Name})
+		}
+	}
+	if len(matches) == 0 {
+		return s
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		b.WriteString(s[last:m.start])
+		b.WriteString(v.token(m.name, s[m.start:m.end]))
+		last = m.end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,314 @@
+// Package redact masks sensitive values in chat prompts before they leave the
+// process and restores them in the model's reply.
+//
+// Client wraps a chat.Client. It replaces the email addresses, card numbers,
+// phone numbers and custom patterns found in message content, reasoning and
+// tool call arguments with tokens such as "[EMAIL_1]", sends the redacted
+// request, and replaces the tokens in the response, streamed deltas included,
+// with the original values:
+//
+//	safe := redact.New(client.Chat, redact.Config{
+//	    Detectors: append(redact.DefaultDetectors, redact.Pattern("employee_id", `\bE\d{6}\b`)),
+//	})
+//	resp, err := safe.Create(ctx, req)
+//
+// Tokens are issued per request, so the model sees the same token wherever a
+// value repeats within a conversation.
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+)
+
+// Config configures a Client.
+type Config struct {
+	// Detectors find the values to redact, in priority order. Nil uses
+	// DefaultDetectors.
+	Detectors []Detector
+	// KeepTokens leaves tokens in responses instead of restoring the values.
+	KeepTokens bool
+}
+
+// Client is a chat.Client that redacts requests and restores responses.
+type Client struct {
+	next chat.Client
+	cfg  Config
+}
+
+var _ chat.Client = (*Client)(nil)
+
+// New returns a Client sending redacted requests to next.
+func New(next chat.Client, cfg Config) *Client {
+	if cfg.Detectors == nil {
+		cfg.Detectors = DefaultDetectors
+	}
+	return &Client{next: next, cfg: cfg}
+}
+
+// Create redacts req, sends it, and restores the values in the response.
+func (c *Client) Create(ctx context.Context, req *chat.ChatRequest) (*chat.ChatResponse, error) {
+	redacted, vault, err := c.Redact(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.next.Create(ctx, redacted)
+	if err != nil || c.cfg.KeepTokens || vault.Len() == 0 {
+		return resp, err
+	}
+	for i := range resp.Choices {
+		restoreMessage(resp.Choices[i].Message, vault)
+		restoreMessage(resp.Choices[i].Delta, vault)
+	}
+	return resp, nil
+}
+
+// CreateStream redacts req, starts the stream, and restores the values in the
+// deltas. A token split across chunks is held back until it is complete, so
+// content may arrive a chunk later than it would otherwise.
+func (c *Client) CreateStream(ctx context.Context, req *chat.ChatRequest, opts ...chat.StreamOption) (*chat.StreamReader, error) {
+	redacted, vault, err := c.Redact(req)
+	if err != nil {
+		return nil, err
+	}
+	sr, err := c.next.CreateStream(ctx, redacted, opts...)
+	if err != nil || c.cfg.KeepTokens || vault.Len() == 0 {
+		return sr, err
+	}
+	return sr.Transform(newStreamRestorer(vault).chunk), nil
+}
+
+// Redact returns a copy of req with sensitive values replaced by tokens, and
+// the vault that restores them. req is not modified; a nil req is treated as
+// an empty request, as the chat service does.
+func (c *Client) Redact(req *chat.ChatRequest) (*chat.ChatRequest, *Vault, error) {
+	if req == nil {
+		req = &chat.ChatRequest{}
+	}
+	vault := newVault()
+	out := *req
+	out.Prompt = vault.redact(req.Prompt, c.cfg.Detectors)
+	if req.Messages != nil {
+		out.Messages = make([]chat.Message, len(req.Messages))
+	}
+	for i, m := range req.Messages {
+		content, err := c.redactContent(m.Content, vault)
+		if err != nil {
+			return nil, nil, err
+		}
+		m.Content = content
+		m.Reasoning = vault.redact(m.Reasoning, c.cfg.Detectors)
+		if len(m.ToolCalls) > 0 {
+			calls := make([]chat.ToolCall, len(m.ToolCalls))
+			for j, tc := range m.ToolCalls {
+				tc.Function.Arguments = vault.redact(tc.Function.Arguments, c.cfg.Detectors)
+				calls[j] = tc
+			}
+			m.ToolCalls = calls
+		}
+		out.Messages[i] = m
+	}
+	return &out, vault, nil
+}
+
+// redactContent redacts a message's content: a string or content parts. Other
+// content types are converted to parts first, and rejected if they do not
+// convert, rather than sent unredacted.
+func (c *Client) redactContent(content any, vault *Vault) (any, error) {
+	switch content := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return vault.redact(content, c.cfg.Detectors), nil
+	case []chat.ContentPart:
+		parts := make([]chat.ContentPart, len(content))
+		for i, p := range content {
+			p.Text = vault.redact(p.Text, c.cfg.Detectors)
+			parts[i] = p
+		}
+		return parts, nil
+	default:
+		data, err := json.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("redact: message content %T: %w", content, err)
+		}
+		var parts []chat.ContentPart
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return nil, fmt.Errorf("redact: unsupported message content %T", content)
+		}
+		return c.redactContent(parts, vault)
+	}
+}
+
+// restoreMessage restores the values in a response message or delta.
+func restoreMessage(m *chat.Message, vault *Vault) {
+	if m == nil {
+		return
+	}
+	m.Content = restoreContent(m.Content, vault)
+	m.Reasoning = vault.Restore(m.Reasoning)
+	for i := range m.ToolCalls {
+		m.ToolCalls[i].Function.Arguments = vault.Restore(m.ToolCalls[i].Function.Arguments)
+	}
+}
+
+func restoreContent(content any, vault *Vault) any {
+	switch content := content.(type) {
+	case string:
+		return vault.Restore(content)
+	case []chat.ContentPart:
+		for i := range content {
+			content[i].Text = vault.Restore(content[i].Text)
+		}
+	case []any:
+		for _, p := range content {
+			if part, ok := p.(map[string]any); ok {
+				if text, ok := part["text"].(string); ok {
+					part["text"] = vault.Restore(text)
+				}
+			}
+		}
+	}
+	return content
+}
+
+// field identifies a streamed text: the content, reasoning or the arguments
+// of one tool call of a choice.
+type field struct {
+	choice int
+	kind   fieldKind
+	tool   int
+}
+
+type fieldKind int
+
+const (
+	fieldContent fieldKind = iota
+	fieldReasoning
+	fieldArguments
+)
+
+// streamRestorer restores values in stream deltas, holding back the tail of
+// a delta that may be the start of a token.
+type streamRestorer struct {
+	vault   *Vault
+	pending map[field]string
+	last    chat.StreamChunk // metadata for the chunk flushed at the end
+}
+
+func newStreamRestorer(vault *Vault) *streamRestorer {
+	return &streamRestorer{vault: vault, pending: make(map[field]string)}
+}
+
+// chunk is the chat.StreamReader.Transform function.
+func (r *streamRestorer) chunk(chunk *chat.StreamChunk, _ error) []*chat.StreamChunk {
+	if chunk == nil {
+		return r.flushAll()
+	}
+	r.last = chat.StreamChunk{ID: chunk.ID, Object: chunk.Object, Created: chunk.Created, Model: chunk.Model, Provider: chunk.Provider}
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if d := choice.Delta; d != nil {
+			if s, ok := d.Content.(string); ok {
+				d.Content = r.feed(field{choice: choice.Index, kind: fieldContent}, s)
+			}
+			d.Reasoning = r.feed(field{choice: choice.Index, kind: fieldReasoning}, d.Reasoning)
+			for j := range d.ToolCalls {
+				tc := &d.ToolCalls[j]
+				tc.Function.Arguments = r.feed(field{choice: choice.Index, kind: fieldArguments, tool: toolIndex(tc, j)}, tc.Function.Arguments)
+			}
+		}
+		if choice.FinishReason != "" {
+			r.flush(choice)
+		}
+	}
+	return []*chat.StreamChunk{chunk}
+}
+
+// feed appends s to the pending text of f and returns what can be restored
+// and released.
+func (r *streamRestorer) feed(f field, s string) string {
+	text := r.pending[f] + s
+	if text == "" {
+		return ""
+	}
+	hold := 0
+	if i := strings.LastIndexByte(text, '['); i >= 0 && !strings.Contains(text[i:], "]") && len(text)-i < r.vault.tokenLen() {
+		hold = len(text) - i
+	}
+	if hold > 0 {
+		r.pending[f] = text[len(text)-hold:]
+	} else {
+		delete(r.pending, f)
+	}
+	return r.vault.Restore(text[:len(text)-hold])
+}
+
+// flush moves the held-back text of choice into its delta.
+func (r *streamRestorer) flush(choice *chat.Choice) {
+	for f, s := range r.pending {
+		if f.choice != choice.Index {
+			continue
+		}
+		delete(r.pending, f)
+		if choice.Delta == nil {
+			choice.Delta = &chat.Message{}
+		}
+		d := choice.Delta
+		switch f.kind {
+		case fieldContent:
+			text, _ := d.Content.(string)
+			d.Content = text + s
+		case fieldReasoning:
+			d.Reasoning += s
+		case fieldArguments:
+			found := false
+			for j := range d.ToolCalls {
+				if toolIndex(&d.ToolCalls[j], j) == f.tool {
+					d.ToolCalls[j].Function.Arguments += s
+					found = true
+					break
+				}
+			}
+			if !found {
+				idx := f.tool
+				d.ToolCalls = append(d.ToolCalls, chat.ToolCall{Index: &idx, Function: chat.FunctionCall{Arguments: s}})
+			}
+		}
+	}
+}
+
+// flushAll returns a chunk carrying the text still held back when the stream
+// ends, if any.
+func (r *streamRestorer) flushAll() []*chat.StreamChunk {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	chunk := r.last
+	seen := make(map[int]bool)
+	for f := range r.pending {
+		if !seen[f.choice] {
+			seen[f.choice] = true
+			chunk.Choices = append(chunk.Choices, chat.Choice{Index: f.choice})
+		}
+	}
+	sort.Slice(chunk.Choices, func(i, j int) bool { return chunk.Choices[i].Index < chunk.Choices[j].Index })
+	for i := range chunk.Choices {
+		r.flush(&chunk.Choices[i])
+	}
+	return []*chat.StreamChunk{&chunk}
+}
+
+// toolIndex returns the index of a tool call delta, defaulting to its position
+// as chat.StreamReader.ToolCalls does.
+func toolIndex(tc *chat.ToolCall, pos int) int {
+	if tc.Index != nil {
+		return *tc.Index
+	}
+	return pos
+}
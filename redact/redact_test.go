@@ -0,0 +1,132 @@
+package redact_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/openroutertest"
+	"github.com/MetaDiv-AI/openrouter/redact"
+)
+
+const secret = "Email jane.doe@example.com or call +1 415 555 0100."
+
+func TestRedact(t *testing.T) {
+	c := redact.New(nil, redact.Config{})
+	tests := []struct {
+		name string
+		req  *chat.ChatRequest
+		want string // JSON of the redacted messages
+	}{
+		{"nil request", nil, "null"},
+		{"nil messages", &chat.ChatRequest{Model: "m"}, "null"},
+		{"empty messages", &chat.ChatRequest{Messages: []chat.Message{}}, "[]"},
+		{
+			name: "string content",
+			req:  &chat.ChatRequest{Messages: []chat.Message{{Role: "user", Content: secret}}},
+			want: `[{"role":"user","content":"Email [EMAIL_1] or call [PHONE_1]."}]`,
+		},
+		{
+			name: "content parts and tool arguments",
+			req: &chat.ChatRequest{Messages: []chat.Message{
+				{Role: "user", Content: []chat.ContentPart{{Type: "text", Text: "to jane.doe@example.com"}}},
+				{Role: "assistant", ToolCalls: []chat.ToolCall{{
+					ID: "call_1", Type: "function",
+					Function: chat.FunctionCall{Name: "send", Arguments: `{"to":"jane.doe@example.com"}`},
+				}}},
+			}},
+			want: `[{"role":"user","content":[{"type":"text","text":"to [EMAIL_1]"}]},` +
+				`{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"send","arguments":"{\"to\":\"[EMAIL_1]\"}"}}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before []byte
+			if tt.req != nil {
+				before, _ = json.Marshal(tt.req)
+			}
+			out, vault, err := c.Redact(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(out.Messages)
+			if string(got) != tt.want {
+				t.Errorf("messages = %s\nwant       %s", got, tt.want)
+			}
+			if tt.req != nil {
+				after, _ := json.Marshal(tt.req)
+				if string(after) != string(before) {
+					t.Errorf("request modified: %s", after)
+				}
+			}
+			if restored := vault.Restore(string(got)); strings.Contains(tt.want, "[EMAIL_1]") && !strings.Contains(restored, "jane.doe@example.com") {
+				t.Errorf("Restore(%s) = %s", got, restored)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// The reply quotes both tokens; the stream splits them across chunks.
+	reply := "Sent to [EMAIL_1], will call [PHONE_1]."
+	split := openroutertest.Reply{Events: []openroutertest.Event{
+		delta("Sent to [EMA"), delta("IL_1], will call [PH"), delta("ONE_"), delta("1]."),
+	}}
+	noEmail := func(r openroutertest.Request) error {
+		if strings.Contains(string(r.Body), "example.com") {
+			return fmt.Errorf("request leaked the address: %s", r.Body)
+		}
+		return nil
+	}
+	want := "Sent to jane.doe@example.com, will call +1 415 555 0100."
+
+	srv := openroutertest.NewServer(t)
+	c := redact.New(srv.Client().Chat, redact.Config{})
+	req := &chat.ChatRequest{Model: "m", Messages: []chat.Message{{Role: "user", Content: secret}}}
+
+	t.Run("create", func(t *testing.T) {
+		srv.Chat(openroutertest.Text(reply).Expect(noEmail))
+		resp, err := c.Create(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Choices[0].Message.Content; got != want {
+			t.Errorf("content = %q, want %q", got, want)
+		}
+	})
+	for name, r := range map[string]openroutertest.Reply{"stream": openroutertest.Text(reply), "split tokens": split} {
+		t.Run(name, func(t *testing.T) {
+			srv.Chat(r.Expect(noEmail))
+			sr, err := c.CreateStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := sr.ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("content = %q, want %q", got, want)
+			}
+		})
+	}
+	t.Run("keep tokens", func(t *testing.T) {
+		srv.Chat(openroutertest.Text(reply))
+		resp, err := redact.New(srv.Client().Chat, redact.Config{KeepTokens: true}).Create(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Choices[0].Message.Content; got != reply {
+			t.Errorf("content = %q, want %q", got, reply)
+		}
+	})
+}
+
+func delta(s string) openroutertest.Event {
+	return openroutertest.Event{Chunk: &chat.StreamChunk{
+		Choices: []chat.Choice{{Delta: &chat.Message{Role: "assistant", Content: s}}},
+	}}
+}
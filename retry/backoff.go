@@ -0,0 +1,29 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff configures exponential backoff between attempts.
+type Backoff struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+}
+
+// Delay returns the jittered wait after the given 1-based failed attempt.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := float64(b.InitialInterval) * math.Pow(b.Multiplier, float64(attempt-1))
+	if d > float64(b.MaxInterval) {
+		d = float64(b.MaxInterval)
+	}
+	return jitter(time.Duration(d))
+}
+
+// jitter spreads d by ±15% to avoid synchronized retries.
+func jitter(d time.Duration) time.Duration {
+	j := time.Duration(rand.Float64() * 0.3 * float64(d))
+	return d + j - time.Duration(math.Round(0.15*float64(d)))
+}
@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/MetaDiv-AI/openrouter/errors"
+)
+
+// DefaultClassifier retries API errors whose status is transient (see
+// OpenRouterError.Retryable) and connection failures that happened before the
+// request was sent. For idempotent requests it also retries client timeouts,
+// connection resets and truncated responses, since repeating them is harmless.
+func DefaultClassifier(err error, idempotent bool) bool {
+	if err == nil {
+		return false
+	}
+	var oerr *errors.OpenRouterError
+	if stderrors.As(err, &oerr) {
+		return oerr.Retryable()
+	}
+	if isDialError(err) {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+	if stderrors.Is(err, context.DeadlineExceeded) ||
+		stderrors.Is(err, syscall.ECONNRESET) ||
+		stderrors.Is(err, io.ErrUnexpectedEOF) ||
+		stderrors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr)
+}
+
+// isDialError reports whether err happened while establishing the connection,
+// i.e. the server cannot have seen the request.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if stderrors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if stderrors.As(err, &dnsErr) {
+		return true
+	}
+	return stderrors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryAfter returns the server-requested wait carried by err, if any.
+func retryAfter(err error) time.Duration {
+	var oerr *errors.OpenRouterError
+	if stderrors.As(err, &oerr) {
+		return oerr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,170 @@
+// Package retry configures how the client retries failed requests.
+//
+// A Policy is set on the client with openrouter.WithRetryPolicy and can be
+// overridden for a single call by attaching another Policy to the context:
+//
+//	ctx = retry.WithPolicy(ctx, retry.NoRetry())
+//	resp, err := client.Chat.Create(ctx, req)
+package retry
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+)
+
+// Default policy values.
+const (
+	DefaultMaxAttempts     = 4
+	DefaultInitialInterval = time.Second
+	DefaultMaxInterval     = 30 * time.Second
+	DefaultMultiplier      = 2
+)
+
+// Classifier reports whether err is worth retrying. idempotent is true when the
+// request can be repeated safely even if it may already have reached the server
+// (e.g. GET /models, or any request when Policy.AssumeIdempotent is set).
+type Classifier func(err error, idempotent bool) bool
+
+// Attempt describes a failed attempt that is about to be retried.
+type Attempt struct {
+	Number  int           // 1-based number of the attempt that failed
+	Err     error         // error returned by the attempt
+	Delay   time.Duration // wait before the next attempt
+	Elapsed time.Duration // time since the first attempt started
+}
+
+// Policy controls retries. Zero-valued fields take their value from DefaultPolicy,
+// so use NoRetry (MaxAttempts 1) to disable retries.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// Backoff configures the wait between attempts. A Retry-After header on
+	// the response takes precedence when it asks for a longer wait.
+	Backoff Backoff
+	// MaxElapsedTime stops retrying once the next attempt would start later
+	// than this after the first one. Zero means no limit.
+	MaxElapsedTime time.Duration
+	// Classifier decides which errors are retried. Defaults to DefaultClassifier.
+	Classifier Classifier
+	// AssumeIdempotent treats every request as idempotent. Chat completions have
+	// no server-side effects beyond billing, so callers that prefer a duplicate
+	// charge over a failed call can enable this to retry mid-request network errors.
+	AssumeIdempotent bool
+	// OnRetry, if set, is called before waiting for each retry.
+	OnRetry func(Attempt)
+}
+
+// DefaultPolicy returns the policy used when none is configured.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff: Backoff{
+			InitialInterval: DefaultInitialInterval,
+			MaxInterval:     DefaultMaxInterval,
+			Multiplier:      DefaultMultiplier,
+		},
+		Classifier: DefaultClassifier,
+	}
+}
+
+// NoRetry returns a policy that makes a single attempt.
+func NoRetry() Policy {
+	p := DefaultPolicy()
+	p.MaxAttempts = 1
+	return p
+}
+
+func (p Policy) withDefaults() Policy {
+	def := DefaultPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.Backoff.InitialInterval <= 0 {
+		p.Backoff.InitialInterval = def.Backoff.InitialInterval
+	}
+	if p.Backoff.MaxInterval <= 0 {
+		p.Backoff.MaxInterval = def.Backoff.MaxInterval
+	}
+	if p.Backoff.Multiplier < 1 {
+		p.Backoff.Multiplier = def.Backoff.Multiplier
+	}
+	if p.Classifier == nil {
+		p.Classifier = def.Classifier
+	}
+	return p
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a context that overrides the client's retry policy for calls made with it.
+func WithPolicy(ctx context.Context, p Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+// FromContext returns the policy attached with WithPolicy, if any.
+func FromContext(ctx context.Context) (Policy, bool) {
+	p, ok := ctx.Value(policyKey{}).(Policy)
+	return p, ok
+}
+
+// Do runs fn until it succeeds, the policy gives up, or ctx is done.
+// Errors are never retried once ctx itself is done, so a cancelled or expired
+// parent context is reported immediately instead of being mistaken for a
+// transient client timeout.
+func Do(ctx context.Context, p Policy, idempotent bool, fn func() error) error {
+	p = p.withDefaults()
+	idempotent = idempotent || p.AssumeIdempotent
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var stop *stopError
+		if stderrors.As(err, &stop) {
+			return stop.err
+		}
+		if ctx.Err() != nil || attempt >= p.MaxAttempts || !p.Classifier(err, idempotent) {
+			return err
+		}
+
+		delay := p.Backoff.Delay(attempt)
+		if ra := retryAfter(err); ra > delay {
+			delay = ra
+		}
+		elapsed := time.Since(start)
+		if p.MaxElapsedTime > 0 && elapsed+delay > p.MaxElapsedTime {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(Attempt{Number: attempt, Err: err, Delay: delay, Elapsed: elapsed})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Stop wraps err so that Do returns it immediately without consulting the
+// classifier, e.g. once a streamed response has started to be consumed.
+func Stop(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stopError{err: err}
+}
+
+type stopError struct {
+	err error
+}
+
+func (e *stopError) Error() string { return e.err.Error() }
+
+func (e *stopError) Unwrap() error { return e.err }
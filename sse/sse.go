@@ -0,0 +1,224 @@
+// Package sse decodes server-sent event streams as specified by the WHATWG
+// HTML standard (https://html.spec.whatwg.org/multipage/server-sent-events.html).
+//
+// It handles CR, LF and CRLF line endings, a leading byte order mark, the
+// data, event, id and retry fields (with or without a space after the colon),
+// data split across several lines, and comment lines.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+)
+
+// DefaultMaxLineSize is the longest line the Decoder accepts by default.
+const DefaultMaxLineSize = 1024 * 1024
+
+// Event is a dispatched server-sent event, or a comment line when the Decoder
+// emits comments.
+type Event struct {
+	// Type is the event type; "message" when the stream did not set one.
+	Type string
+	// Data is the event data, with lines joined by "\n". It is only valid
+	// until the next call to Next.
+	Data []byte
+	// ID is the last event ID seen on the stream, as the spec requires.
+	ID string
+	// Retry is the reconnection time from the most recent retry field, or 0.
+	Retry time.Duration
+	// Comment holds the text of a comment line, without the leading colon.
+	// It is only set on comment events.
+	Comment string
+}
+
+// IsComment reports whether the event is a comment line.
+func (e Event) IsComment() bool {
+	return e.Type == ""
+}
+
+// Decoder reads events from an io.Reader.
+type Decoder struct {
+	r        *bufio.Reader
+	max      int
+	line     []byte
+	skipLF   bool
+	comments bool
+	started  bool
+
+	data    []byte
+	hasData bool
+	typ     string
+	lastID  string
+	retry   time.Duration
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), max: DefaultMaxLineSize}
+}
+
+// Buffer sets the buffer used for lines longer than the read buffer and the
+// maximum line size, like bufio.Scanner.Buffer. It must be called before the
+// first call to Next.
+func (d *Decoder) Buffer(buf []byte, max int) {
+	d.line, d.max = buf[:0], max
+}
+
+// Reset discards the decoder's state and makes it read from r, keeping its
+// buffers, maximum line size and comment setting, so decoders can be pooled.
+func (d *Decoder) Reset(r io.Reader) {
+	d.r.Reset(r)
+	d.line, d.skipLF, d.started = d.line[:0], false, false
+	d.data, d.hasData = d.data[:0], false
+	d.typ, d.lastID, d.retry = "", "", 0
+}
+
+// EmitComments makes Next return comment lines (such as keep-alive messages)
+// as events with only Comment set.
+func (d *Decoder) EmitComments() {
+	d.comments = true
+}
+
+// Next returns the next event. It returns io.EOF at the end of the stream; an
+// event that was not terminated by a blank line, like a last line without a
+// line ending, is discarded, per the spec.
+func (d *Decoder) Next() (Event, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+		if !d.started {
+			d.started = true
+			line = bytes.TrimPrefix(line, []byte("\xEF\xBB\xBF"))
+		}
+
+		if len(line) == 0 {
+			if ev, ok := d.dispatch(); ok {
+				return ev, nil
+			}
+			continue
+		}
+		if line[0] == ':' {
+			if d.comments {
+				return Event{Comment: string(bytes.TrimPrefix(line[1:], []byte(" ")))}, nil
+			}
+			continue
+		}
+		field, value := ParseLine(line)
+		d.process(field, value)
+	}
+}
+
+// readLine returns the next line without its CR, LF or CRLF ending. The line
+// is only valid until the next call. An unterminated last line is not a line,
+// so it is discarded at the end of the stream.
+func (d *Decoder) readLine() ([]byte, error) {
+	if d.skipLF {
+		// The last line ended in CR, which may be the first half of a CRLF.
+		// This is checked now rather than then, so a CR-terminated line is
+		// not held back waiting for the next byte.
+		d.skipLF = false
+		if b, err := d.r.Peek(1); err == nil && b[0] == '\n' {
+			_, _ = d.r.Discard(1)
+		}
+	}
+	d.line = d.line[:0]
+	for {
+		if d.r.Buffered() == 0 {
+			if _, err := d.r.Peek(1); err != nil {
+				return nil, err
+			}
+		}
+		buf, _ := d.r.Peek(d.r.Buffered())
+		i := bytes.IndexAny(buf, "\r\n")
+		if i < 0 {
+			if len(d.line)+len(buf) > d.max {
+				return nil, bufio.ErrTooLong
+			}
+			d.line = append(d.line, buf...)
+			_, _ = d.r.Discard(len(buf))
+			continue
+		}
+		if len(d.line)+i > d.max {
+			return nil, bufio.ErrTooLong
+		}
+		line := buf[:i]
+		if len(d.line) > 0 {
+			d.line = append(d.line, line...)
+			line = d.line
+		}
+		d.skipLF = buf[i] == '\r'
+		_, _ = d.r.Discard(i + 1)
+		return line, nil
+	}
+}
+
+func (d *Decoder) process(field, value []byte) {
+	switch string(field) {
+	case "event":
+		d.typ = string(value)
+	case "data":
+		if d.hasData {
+			d.data = append(d.data, '\n')
+		}
+		d.data = append(d.data, value...)
+		d.hasData = true
+	case "id":
+		if bytes.IndexByte(value, 0) < 0 {
+			d.lastID = string(value)
+		}
+	case "retry":
+		if ms, ok := parseDigits(value); ok {
+			d.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
+
+// dispatch builds the pending event and resets the buffers. It reports false
+// when there is no data to dispatch.
+func (d *Decoder) dispatch() (Event, bool) {
+	typ := d.typ
+	d.typ = ""
+	if !d.hasData {
+		return Event{}, false
+	}
+	d.hasData = false
+	if typ == "" {
+		typ = "message"
+	}
+	ev := Event{Type: typ, Data: d.data, ID: d.lastID, Retry: d.retry}
+	d.data = d.data[:0]
+	return ev, true
+}
+
+// ParseLine splits a non-comment line into its field name and value. The
+// value is everything after the first colon, minus one leading space; a line
+// without a colon is a field with an empty value.
+func ParseLine(line []byte) (field, value []byte) {
+	i := bytes.IndexByte(line, ':')
+	if i < 0 {
+		return line, nil
+	}
+	field, value = line[:i], line[i+1:]
+	if len(value) > 0 && value[0] == ' ' {
+		value = value[1:]
+	}
+	return field, value
+}
+
+func parseDigits(b []byte) (int64, bool) {
+	if len(b) == 0 || len(b) > 18 {
+		return 0, false
+	}
+	var n int64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int64(c-'0')
+	}
+	return n, true
+}
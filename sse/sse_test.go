@@ -0,0 +1,148 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func FuzzDecoder(f *testing.F) {
+	seeds := []string{
+		"data: hello\n\n",
+		"data: hello\r\n\r\n",
+		"data: hello\r\rdata: world\r\r",
+		"\xEF\xBB\xBFdata: bom\n\n",
+		"\xEF\xBB\xBF\xEF\xBB\xBFdata: two boms\n\n",
+		"data: one\ndata: two\ndata\ndata:three\n\n",
+		": OPENROUTER PROCESSING\n\ndata: {\"id\":\"x\"}\n\n",
+		":comment\r\nevent: ping\r\nid: 7\r\nretry: 1500\r\ndata: a\r\n\r\n",
+		"event: only\n\nid: 1\nretry: x\n\ndata\n\n",
+		"id: a\x00b\ndata: nul id\n\n",
+		"data: unterminated",
+		"data: cr at end\r",
+		"\n\n\r\n\r\r",
+		"field without colon\ndata:  two spaces\n\n",
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s), []byte{1})
+		f.Add([]byte(s), []byte{0, 3, 1, 7, 2})
+	}
+	f.Fuzz(func(t *testing.T, input, splits []byte) {
+		if len(input) > DefaultMaxLineSize {
+			t.Skip()
+		}
+		// Stay below the 100 consecutive empty reads bufio gives up after.
+		splits = splits[:min(len(splits), 64)]
+		got, err := decodeAll(&splitReader{data: input, splits: splits})
+		if err != nil {
+			t.Fatalf("decode %q: %v", input, err)
+		}
+		want := referenceDecode(string(input))
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("decode %q split %v:\n got %#v\nwant %#v", input, splits, got, want)
+		}
+	})
+}
+
+// decodeAll reads every event, comments included, copying the data Next
+// reuses. Data is never nil, so events compare regardless of how it was
+// built.
+func decodeAll(r io.Reader) ([]Event, error) {
+	d := NewDecoder(r)
+	d.EmitComments()
+	var events []Event
+	for {
+		ev, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		ev.Data = []byte(string(ev.Data))
+		events = append(events, ev)
+	}
+}
+
+// splitReader returns data in reads sized by splits, then the rest at once.
+type splitReader struct {
+	data   []byte
+	splits []byte
+}
+
+func (r *splitReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := len(r.data)
+	if len(r.splits) > 0 {
+		n = min(n, int(r.splits[0]))
+		r.splits = r.splits[1:]
+	}
+	n = copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// referenceDecode is a direct reading of the spec: normalize line endings,
+// split into lines and interpret them one by one.
+func referenceDecode(input string) []Event {
+	input = strings.TrimPrefix(input, "\xEF\xBB\xBF")
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	input = strings.ReplaceAll(input, "\r", "\n")
+	lines := strings.Split(input, "\n")
+	// The text after the last line break is an unterminated line: it may set
+	// fields, but cannot dispatch an event.
+	lines = lines[:len(lines)-1]
+
+	var (
+		events        []Event
+		data, typ, id string
+		hasData       bool
+		retry         time.Duration
+	)
+	for _, line := range lines {
+		switch {
+		case line == "":
+			if hasData {
+				ev := Event{Type: typ, Data: []byte(data), ID: id, Retry: retry}
+				if ev.Type == "" {
+					ev.Type = "message"
+				}
+				events = append(events, ev)
+			}
+			data, typ, hasData = "", "", false
+		case line[0] == ':':
+			events = append(events, Event{Comment: strings.TrimPrefix(line[1:], " "), Data: []byte("")})
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				typ = value
+			case "data":
+				if hasData {
+					data += "\n"
+				}
+				data += value
+				hasData = true
+			case "id":
+				if !strings.Contains(value, "\x00") {
+					id = value
+				}
+			case "retry":
+				if value != "" && len(value) <= 18 && strings.Trim(value, "0123456789") == "" {
+					var ms int64
+					for _, c := range value {
+						ms = ms*10 + int64(c-'0')
+					}
+					retry = time.Duration(ms) * time.Millisecond
+				}
+			}
+		}
+	}
+	return events
+}
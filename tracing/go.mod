@@ -0,0 +1,25 @@
+module github.com/MetaDiv-AI/openrouter/tracing
+
+go 1.23.2
+
+require (
+	github.com/MetaDiv-AI/openrouter v1.3.0
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require (
+	github.com/MetaDiv-AI/http_caller v1.0.0 // indirect
+	github.com/MetaDiv-AI/logger v1.0.0 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.1 // indirect
+)
+
+// The client is developed alongside this module. The replace is ignored when
+// this module is a dependency, so the root must be tagged v1.3.0, the release
+// that adds observe, before this module is tagged tracing/v*.
+replace github.com/MetaDiv-AI/openrouter => ../
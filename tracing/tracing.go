@@ -0,0 +1,242 @@
+// Package tracing creates OpenTelemetry spans for client operations, with
+// attributes following the OpenTelemetry GenAI semantic conventions, and
+// propagates the trace context on outgoing requests.
+//
+//	client, _ := openrouter.NewClient(
+//	    openrouter.WithHooks(tracing.New(tracing.WithTracerProvider(tp))),
+//	)
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/MetaDiv-AI/openrouter/chat"
+	"github.com/MetaDiv-AI/openrouter/embeddings"
+	"github.com/MetaDiv-AI/openrouter/observe"
+)
+
+// ScopeName is the instrumentation scope of the spans.
+const ScopeName = "github.com/MetaDiv-AI/openrouter/tracing"
+
+// System is the gen_ai.system and gen_ai.provider.name value.
+const System = "openrouter"
+
+// Option configures the tracing hooks.
+type Option func(*config)
+
+type config struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the tracer provider; otel.GetTracerProvider() by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = tp
+	}
+}
+
+// WithPropagator sets the propagator that injects the trace context into
+// request headers; otel.GetTextMapPropagator() by default.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = p
+	}
+}
+
+// New returns hooks that trace every operation as a client span named
+// "{operation} {model}", e.g. "chat openai/gpt-4o". Each HTTP attempt is
+// recorded as a span event, and the span ends when the operation ends, which
+// for streams is when the stream has been fully read or closed.
+func New(opts ...Option) observe.Hooks {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.provider == nil {
+		c.provider = otel.GetTracerProvider()
+	}
+	if c.propagator == nil {
+		c.propagator = otel.GetTextMapPropagator()
+	}
+	tracer := c.provider.Tracer(ScopeName)
+
+	return observe.Hooks{
+		OnStart: func(ctx context.Context, op observe.Operation) context.Context {
+			ctx, _ = tracer.Start(ctx, spanName(op),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithTimestamp(op.Start),
+				trace.WithAttributes(startAttributes(op)...),
+			)
+			return ctx
+		},
+		OnRequest: func(ctx context.Context, req *http.Request) {
+			c.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+			span := trace.SpanFromContext(ctx)
+			if !span.IsRecording() {
+				return
+			}
+			attrs := []attribute.KeyValue{attribute.String("server.address", req.URL.Hostname())}
+			if port := req.URL.Port(); port != "" {
+				if p, err := strconv.Atoi(port); err == nil {
+					attrs = append(attrs, attribute.Int("server.port", p))
+				}
+			}
+			span.SetAttributes(attrs...)
+		},
+		OnAttempt: func(ctx context.Context, op observe.Operation, a observe.Attempt) {
+			attrs := []attribute.KeyValue{
+				attribute.Int("openrouter.attempt", a.Number),
+				attribute.Float64("openrouter.attempt.duration", a.Duration.Seconds()),
+			}
+			if a.StatusCode != 0 {
+				attrs = append(attrs, attribute.Int("http.response.status_code", a.StatusCode))
+			}
+			if a.Err != nil {
+				attrs = append(attrs, attribute.String("error.type", observe.ErrorType(a.Err)))
+			}
+			trace.SpanFromContext(ctx).AddEvent("http.attempt", trace.WithAttributes(attrs...))
+		},
+		OnFirstToken: func(ctx context.Context, op observe.Operation, ttft time.Duration) {
+			trace.SpanFromContext(ctx).AddEvent("gen_ai.first_token")
+		},
+		OnEnd: func(ctx context.Context, op observe.Operation, res observe.Result) {
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(endAttributes(op, res)...)
+			if res.Err != nil {
+				span.RecordError(res.Err)
+				span.SetStatus(codes.Error, res.Err.Error())
+			}
+			span.End(trace.WithTimestamp(op.Start.Add(res.Duration)))
+		},
+	}
+}
+
+// genAIOperation maps an operation to gen_ai.operation.name, or "".
+func genAIOperation(op observe.Operation) string {
+	switch op.Name {
+	case observe.OpChat, observe.OpChatStream:
+		return "chat"
+	case observe.OpEmbeddings:
+		return "embeddings"
+	}
+	return ""
+}
+
+func spanName(op observe.Operation) string {
+	name := genAIOperation(op)
+	if name == "" {
+		return op.Name
+	}
+	if op.Model != "" {
+		return name + " " + op.Model
+	}
+	return name
+}
+
+func startAttributes(op observe.Operation) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("openrouter.operation", op.Name),
+		attribute.String("url.path", op.Endpoint),
+	}
+	if name := genAIOperation(op); name != "" {
+		attrs = append(attrs,
+			attribute.String("gen_ai.operation.name", name),
+			attribute.String("gen_ai.system", System),
+			attribute.String("gen_ai.provider.name", System),
+		)
+	}
+	if op.Model != "" {
+		attrs = append(attrs, attribute.String("gen_ai.request.model", op.Model))
+	}
+	switch req := op.Request.(type) {
+	case *chat.ChatRequest:
+		attrs = append(attrs, chatRequestAttributes(req)...)
+	case *embeddings.CreateRequest:
+		if list, ok := req.Input.([]string); ok {
+			attrs = append(attrs, attribute.Int("openrouter.embeddings.inputs", len(list)))
+		}
+	}
+	return attrs
+}
+
+func chatRequestAttributes(req *chat.ChatRequest) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if req.Temperature != nil {
+		attrs = append(attrs, attribute.Float64("gen_ai.request.temperature", *req.Temperature))
+	}
+	if req.TopP != nil {
+		attrs = append(attrs, attribute.Float64("gen_ai.request.top_p", *req.TopP))
+	}
+	if req.TopK != nil {
+		attrs = append(attrs, attribute.Int("gen_ai.request.top_k", *req.TopK))
+	}
+	if req.MaxTokens != nil {
+		attrs = append(attrs, attribute.Int("gen_ai.request.max_tokens", *req.MaxTokens))
+	}
+	if req.Seed != nil {
+		attrs = append(attrs, attribute.Int("gen_ai.request.seed", *req.Seed))
+	}
+	if req.PresencePenalty != nil {
+		attrs = append(attrs, attribute.Float64("gen_ai.request.presence_penalty", *req.PresencePenalty))
+	}
+	if req.FrequencyPenalty != nil {
+		attrs = append(attrs, attribute.Float64("gen_ai.request.frequency_penalty", *req.FrequencyPenalty))
+	}
+	switch stop := req.Stop.(type) {
+	case string:
+		attrs = append(attrs, attribute.StringSlice("gen_ai.request.stop_sequences", []string{stop}))
+	case []string:
+		attrs = append(attrs, attribute.StringSlice("gen_ai.request.stop_sequences", stop))
+	}
+	return attrs
+}
+
+func endAttributes(op observe.Operation, res observe.Result) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int("openrouter.attempts", res.Attempts),
+	}
+	if res.Attempts > 1 {
+		attrs = append(attrs, attribute.Int("openrouter.retries", res.Attempts-1))
+	}
+	if res.StatusCode != 0 {
+		attrs = append(attrs, attribute.Int("http.response.status_code", res.StatusCode))
+	}
+	if res.Model != "" {
+		attrs = append(attrs, attribute.String("gen_ai.response.model", res.Model))
+	}
+	if res.ID != "" {
+		attrs = append(attrs, attribute.String("gen_ai.response.id", res.ID))
+	}
+	if res.Provider != "" {
+		attrs = append(attrs, attribute.String("openrouter.provider", res.Provider))
+	}
+	if len(res.FinishReasons) > 0 {
+		attrs = append(attrs, attribute.StringSlice("gen_ai.response.finish_reasons", res.FinishReasons))
+	}
+	if genAIOperation(op) != "" && res.Err == nil {
+		attrs = append(attrs, attribute.Int("gen_ai.usage.input_tokens", res.InputTokens))
+		if op.Name != observe.OpEmbeddings {
+			attrs = append(attrs, attribute.Int("gen_ai.usage.output_tokens", res.OutputTokens))
+		}
+	}
+	if res.Cost != 0 {
+		attrs = append(attrs, attribute.Float64("openrouter.cost", res.Cost))
+	}
+	if res.TimeToFirstToken != 0 {
+		attrs = append(attrs, attribute.Float64("openrouter.time_to_first_token", res.TimeToFirstToken.Seconds()))
+	}
+	if res.Err != nil {
+		attrs = append(attrs, attribute.String("error.type", observe.ErrorType(res.Err)))
+	}
+	return attrs
+}
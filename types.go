@@ -3,6 +3,7 @@ package openrouter
 import (
 	"github.com/MetaDiv-AI/openrouter/chat"
 	"github.com/MetaDiv-AI/openrouter/embeddings"
+	"github.com/MetaDiv-AI/openrouter/models"
 )
 
 // Re-export commonly used types for convenience.
@@ -18,4 +19,8 @@ type (
 	ResponseFormat = chat.ResponseFormat
 	CreateRequest  = embeddings.CreateRequest
 	CreateResponse = embeddings.CreateResponse
+
+	ChatClient       = chat.Client
+	EmbeddingsClient = embeddings.Client
+	ModelsCatalog    = models.Catalog
 )